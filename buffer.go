@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ringBuffer is a fixed-size byte queue shared by one reader and one writer goroutine
+type ringBuffer struct {
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	buf      []byte
+	start    int   // Offset of the oldest buffered byte
+	used     int   // Number of buffered bytes
+	closed   bool  // No more data will be added
+	err      error // Read error that ended the input, if any
+}
+
+// newRingBuffer allocates a ring buffer of the given size
+func newRingBuffer(size int) *ringBuffer {
+	r := &ringBuffer{buf: make([]byte, size)}
+	r.notEmpty = sync.NewCond(&r.mu)
+	r.notFull = sync.NewCond(&r.mu)
+	return r
+}
+
+// free blocks until there is room and returns the contiguous free space, or nil once closed
+func (r *ringBuffer) free() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for r.used == len(r.buf) && !r.closed {
+		r.notFull.Wait()
+	}
+	if r.closed {
+		return nil
+	}
+
+	end := (r.start + r.used) % len(r.buf)
+	if end < r.start {
+		return r.buf[end:r.start]
+	}
+	return r.buf[end:]
+}
+
+// commit makes n bytes written into the slice from free available to the writer
+func (r *ringBuffer) commit(n int) {
+	r.mu.Lock()
+	r.used += n
+	r.mu.Unlock()
+	r.notEmpty.Signal()
+}
+
+// data blocks until bytes are buffered and returns the contiguous oldest chunk.
+// It returns nil and the input error once the buffer is closed and empty.
+func (r *ringBuffer) data() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for r.used == 0 && !r.closed {
+		r.notEmpty.Wait()
+	}
+	if r.used == 0 {
+		return nil, r.err
+	}
+
+	end := min(r.start+r.used, len(r.buf))
+	return r.buf[r.start:end], nil
+}
+
+// consume releases n bytes returned by data back to the reader
+func (r *ringBuffer) consume(n int) {
+	r.mu.Lock()
+	r.start = (r.start + n) % len(r.buf)
+	r.used -= n
+	r.mu.Unlock()
+	r.notFull.Signal()
+}
+
+// close marks the end of the input, recording err if the read failed
+func (r *ringBuffer) close(err error) {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		r.err = err
+	}
+	r.mu.Unlock()
+	r.notEmpty.Broadcast()
+	r.notFull.Broadcast()
+}
+
+// fill returns the number of buffered bytes and the buffer size
+func (r *ringBuffer) fill() (int, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.used, len(r.buf)
+}
+
+// transferBuffered reads and writes in separate goroutines joined by a ring buffer,
+// so a slow consumer doesn't hold up the producer until the buffer fills, and vice versa
+func (p *Progress) transferBuffered(reader io.Reader, writer *bufio.Writer) error {
+	ring := newRingBuffer(int(p.bufferSize))
+	p.buffer.Store(ring)
+
+	input := reader
+	if p.limit > 0 {
+		input = io.LimitReader(reader, p.limit)
+	}
+
+	// Fill the buffer until EOF, a read error or the writer giving up
+	go func() {
+		for {
+			space := ring.free()
+			if space == nil {
+				return
+			}
+			n, err := input.Read(space[:min(len(space), 64*1024)])
+			if n > 0 {
+				p.lastRead.Store(p.now().UnixNano())
+				ring.commit(n)
+			}
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				ring.close(err)
+				return
+			}
+		}
+	}()
+
+	// Drain the buffer to the output
+	for {
+		chunk, err := ring.data()
+		if chunk == nil {
+			if err != nil {
+				p.stopOutput(writer, "")
+				return fmt.Errorf("error reading from stdin: %w", err)
+			}
+			break
+		}
+		chunk = chunk[:min(len(chunk), 64*1024)]
+
+		// Writes are serialized with the final flush so nothing is written after a stop
+		p.writeMu.Lock()
+		if p.stopped() {
+			p.writeMu.Unlock()
+			ring.close(nil)
+			return nil
+		}
+		_, writeErr := writer.Write(chunk)
+		if writeErr == nil {
+			p.bytesRead.Add(int64(len(chunk)))
+		}
+		ring.consume(len(chunk))
+
+		// Flush whenever we've caught up with the input so data keeps flowing
+		if used, _ := ring.fill(); writeErr == nil && used == 0 {
+			if flushErr := writer.Flush(); flushErr != nil {
+				p.writeMu.Unlock()
+				ring.close(nil)
+				return fmt.Errorf("error flushing output: %w", flushErr)
+			}
+		}
+		p.writeMu.Unlock()
+
+		if writeErr != nil {
+			ring.close(nil)
+			return fmt.Errorf("error writing to stdout: %w", writeErr)
+		}
+	}
+
+	return p.finishTransfer(reader, writer)
+}
+
+// bufferText shows how full the --buffer-size buffer is, or nothing without one
+func bufferText(s Snapshot) string {
+	if s.bufferSize == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" buf %3.0f%%", float64(s.bufferUsed)/float64(s.bufferSize)*100)
+}
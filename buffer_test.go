@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRingBufferWrap tests that data wraps around the end of the buffer in order
+func TestRingBufferWrap(t *testing.T) {
+	ring := newRingBuffer(8)
+
+	n := copy(ring.free(), "abcdef")
+	ring.commit(n)
+	chunk, _ := ring.data()
+	if string(chunk) != "abcdef" {
+		t.Fatalf("Expected 'abcdef', got '%s'", chunk)
+	}
+	ring.consume(4)
+
+	// Free space is now the tail, then the head up to the unread "ef"
+	n = copy(ring.free(), "ghij")
+	ring.commit(n)
+	if n != 2 {
+		t.Fatalf("Expected 2 bytes of contiguous space at the tail, got %d", n)
+	}
+	n = copy(ring.free(), "ijkl")
+	ring.commit(n)
+	if used, size := ring.fill(); used != 8 || size != 8 {
+		t.Fatalf("Expected a full buffer of 8, got %d of %d", used, size)
+	}
+
+	var out []byte
+	ring.close(nil)
+	for {
+		chunk, err := ring.data()
+		if chunk == nil {
+			if err != nil {
+				t.Fatalf("data() returned error: %v", err)
+			}
+			break
+		}
+		out = append(out, chunk...)
+		ring.consume(len(chunk))
+	}
+	if string(out) != "efghijkl" {
+		t.Errorf("Expected 'efghijkl', got '%s'", out)
+	}
+}
+
+// TestProcessBuffered tests a transfer through a ring buffer much smaller than the input
+func TestProcessBuffered(t *testing.T) {
+	testData := bytes.Repeat([]byte("0123456789"), 100000)
+	p := &Progress{
+		startTime:   time.Now(),
+		refreshRate: 100 * time.Millisecond,
+		quiet:       true,
+		bufferSize:  1000,
+	}
+
+	output, err := runProcess(t, p, func(w *os.File) {
+		w.Write(testData)
+		w.Close()
+	})
+	if err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+
+	if !bytes.Equal(output, testData) {
+		t.Errorf("Processed data doesn't match input data")
+	}
+	if p.bytesRead.Load() != int64(len(testData)) {
+		t.Errorf("Expected bytesRead to be %d, got %d", len(testData), p.bytesRead.Load())
+	}
+}
+
+// TestProcessBufferedLimit tests that --limit is honoured with a buffer
+func TestProcessBufferedLimit(t *testing.T) {
+	testData := bytes.Repeat([]byte("x"), 10000)
+	p := &Progress{
+		startTime:   time.Now(),
+		refreshRate: 100 * time.Millisecond,
+		quiet:       true,
+		bufferSize:  4096,
+		limit:       5000,
+	}
+
+	output, err := runProcess(t, p, func(w *os.File) {
+		w.Write(testData)
+		w.Close()
+	})
+	if err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+
+	if len(output) != 5000 {
+		t.Errorf("Expected 5000 bytes of output, got %d", len(output))
+	}
+	if p.stopReason != "limit" {
+		t.Errorf("Expected stop reason limit, got '%s'", p.stopReason)
+	}
+}
+
+// TestBufferText tests the buffer fill level shown in the bar
+func TestBufferText(t *testing.T) {
+	p := &Progress{totalSize: 1000, barSize: 10}
+	p.bytesRead.Store(500)
+	if bar := p.buildProgressBar(time.Second); strings.Contains(bar, "buf") {
+		t.Errorf("Expected no buffer level without --buffer-size, got '%s'", bar)
+	}
+
+	ring := newRingBuffer(200)
+	ring.commit(50)
+	p.buffer.Store(ring)
+
+	if bar := p.buildProgressBar(time.Second); !strings.HasSuffix(bar, " buf  25%") {
+		t.Errorf("Expected buffer level of 25%%, got '%s'", bar)
+	}
+}
+
+// TestProcessBufferedLastRead tests that reads count as input activity while the output is stuck
+func TestProcessBufferedLastRead(t *testing.T) {
+	startTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := newMockTime(startTime)
+	inputR, inputW := io.Pipe()
+	release := make(chan struct{})
+	defer close(release)
+
+	p := &Progress{
+		startTime:   startTime,
+		refreshRate: time.Hour,
+		quiet:       true,
+		bufferSize:  1024 * 1024,
+		clock:       clock,
+		input:       inputR,
+		output:      stuckWriter{release},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- p.Process(ctx)
+	}()
+
+	// The first chunk blocks the writer, the next one can only reach the buffer
+	inputW.Write(make([]byte, 64*1024))
+	waitForBuffered := func(written int64, n int) {
+		for i := 0; i < 1000; i++ {
+			if ring := p.buffer.Load(); ring != nil {
+				if used, _ := ring.fill(); used == n && p.bytesRead.Load() == written {
+					return
+				}
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Fatalf("Timed out waiting for %d written and %d buffered bytes", written, n)
+	}
+	waitForBuffered(64*1024, 0)
+
+	clock.Advance(5 * time.Second)
+	inputW.Write(make([]byte, 1000))
+	waitForBuffered(64*1024, 1000)
+
+	if lastRead := time.Unix(0, p.lastRead.Load()); !lastRead.Equal(startTime.Add(5 * time.Second)) {
+		t.Errorf("Expected last read at 5s, got %s", lastRead.Sub(startTime))
+	}
+	if stalled := p.stalledFor(clock.Now()); stalled != 0 {
+		t.Errorf("Expected input not to count as stalled, got %s", stalled)
+	}
+
+	cancel()
+	inputW.Close()
+	<-result
+}
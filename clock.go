@@ -0,0 +1,24 @@
+package main
+
+import "time"
+
+// Clock tells the time. Progress uses it for every timestamp it records, so a
+// fake clock can step a transfer through time without sleeping.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the real wall clock
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// now returns the current time from the configured clock
+func (p *Progress) now() time.Time {
+	if p.clock == nil {
+		return systemClock{}.Now()
+	}
+	return p.clock.Now()
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// configSetting is one name = value line from the config file
+type configSetting struct {
+	name    string
+	value   string
+	line    int
+	profile string // Section the line is in, empty before the first [profile]
+}
+
+// configFile holds the settings read from the config file
+type configFile struct {
+	path     string
+	settings []configSetting
+	profiles []string // Names of the [profile] sections
+}
+
+// hasProfile reports whether the file has a [name] section
+func (c configFile) hasProfile(name string) bool {
+	for _, profile := range c.profiles {
+		if profile == name {
+			return true
+		}
+	}
+	return false
+}
+
+// configPath returns the location of the config file, honouring XDG_CONFIG_HOME
+func configPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "progzer", "config")
+}
+
+// readConfigFile reads name = value lines, skipping blank lines and # comments.
+// A missing file is not an error.
+func readConfigFile(path string) (configFile, error) {
+	if path == "" {
+		return configFile{}, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return configFile{path: path}, nil
+	}
+	if err != nil {
+		return configFile{}, fmt.Errorf("error reading config file: %w", err)
+	}
+	defer f.Close()
+
+	return parseConfig(f, path)
+}
+
+// parseConfig parses config file lines. Settings before the first [profile]
+// header always apply; a bare name is shorthand for name = true.
+func parseConfig(r io.Reader, path string) (configFile, error) {
+	file := configFile{path: path}
+	profile := ""
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			profile = strings.TrimSpace(text[1 : len(text)-1])
+			if profile == "" {
+				return configFile{}, fmt.Errorf("%s:%d: missing profile name", path, line)
+			}
+			if file.hasProfile(profile) {
+				return configFile{}, fmt.Errorf("%s:%d: duplicate profile %q", path, line, profile)
+			}
+			file.profiles = append(file.profiles, profile)
+			continue
+		}
+
+		name, value, found := strings.Cut(text, "=")
+		if !found {
+			value = "true"
+		}
+		name = strings.TrimLeft(strings.TrimSpace(name), "-")
+		if name == "" {
+			return configFile{}, fmt.Errorf("%s:%d: missing option name", path, line)
+		}
+		if name == "profile" && profile != "" {
+			return configFile{}, fmt.Errorf("%s:%d: a profile can't select another profile", path, line)
+		}
+		file.settings = append(file.settings, configSetting{name: name, value: strings.TrimSpace(value), line: line, profile: profile})
+	}
+	if err := scanner.Err(); err != nil {
+		return configFile{}, fmt.Errorf("error reading config file: %w", err)
+	}
+	return file, nil
+}
+
+// envName returns the environment variable for a flag, e.g. PROGZER_BAR_SIZE
+func envName(flagName string) string {
+	return "PROGZER_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// commandLineOnly reports whether a flag runs a one-off action and exits, so
+// it is ignored in the environment and the config file
+func commandLineOnly(name string) bool {
+	switch name {
+	case "version", "get-size", "print-config":
+		return true
+	}
+	return false
+}
+
+// applyDefaults fills in the flags not given on the command line from
+// PROGZER_* variables, the selected profile and the top of the config file,
+// so the precedence is flag > env > profile > file > built-in. It returns
+// where each set flag came from.
+func applyDefaults(fs *flag.FlagSet, file configFile, lookupEnv func(string) (string, bool)) (map[string]string, error) {
+	// Catch typos in every profile, not just the selected one
+	for _, s := range file.settings {
+		if fs.Lookup(s.name) == nil {
+			return nil, fmt.Errorf("%s:%d: unknown option %q", file.path, s.line, s.name)
+		}
+	}
+
+	sources := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		sources[f.Name] = "flag"
+	})
+
+	// Settings outside any profile, then the environment, which may pick the profile
+	if err := applySettings(fs, file, "", sources); err != nil {
+		return nil, err
+	}
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		name := envName(f.Name)
+		value, ok := lookupEnv(name)
+		if !ok || sources[f.Name] == "flag" || commandLineOnly(f.Name) || err != nil {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid value %q for %s: %w", value, name, setErr)
+			return
+		}
+		sources[f.Name] = "env " + name
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	profileFlag := fs.Lookup("profile")
+	if profileFlag == nil || profileFlag.Value.String() == "" {
+		return sources, nil
+	}
+	profile := profileFlag.Value.String()
+	if !file.hasProfile(profile) {
+		return nil, fmt.Errorf("unknown profile %q, no [%s] section in %s", profile, profile, file.path)
+	}
+	if err := applySettings(fs, file, profile, sources); err != nil {
+		return nil, err
+	}
+
+	return sources, nil
+}
+
+// applySettings sets the flags from one section of the config file, leaving
+// those given on the command line or in the environment alone
+func applySettings(fs *flag.FlagSet, file configFile, profile string, sources map[string]string) error {
+	for _, s := range file.settings {
+		if s.profile != profile || commandLineOnly(s.name) {
+			continue
+		}
+		if source := sources[s.name]; source == "flag" || strings.HasPrefix(source, "env ") {
+			continue
+		}
+		if err := fs.Set(s.name, s.value); err != nil {
+			return fmt.Errorf("%s:%d: invalid value %q for %s: %w", file.path, s.line, s.value, s.name, err)
+		}
+
+		sources[s.name] = fmt.Sprintf("file %s:%d", file.path, s.line)
+		if profile != "" {
+			sources[s.name] += " [" + profile + "]"
+		}
+	}
+	return nil
+}
+
+// printConfig lists every option with its effective value and where it came from
+func printConfig(w io.Writer, fs *flag.FlagSet, sources map[string]string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "print-config" {
+			return
+		}
+		source := sources[f.Name]
+		if source == "" {
+			source = "default"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", f.Name, f.Value.String(), source)
+	})
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("error printing config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestFlagSet defines a few options of each kind on a fresh flag set
+func newTestFlagSet(cfg *config) *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.DurationVar(&cfg.refreshRate, "refresh", 100*time.Millisecond, "")
+	fs.IntVar(&cfg.barSize, "bar-size", 34, "")
+	fs.BoolVar(&cfg.quiet, "quiet", false, "")
+	fs.StringVar(&cfg.units, "units", "", "")
+	fs.Var(&sizeValue{bytes: &cfg.limit}, "limit", "")
+	fs.StringVar(&cfg.profile, "profile", "", "")
+	return fs
+}
+
+// mapEnv returns a lookup function over a fixed set of variables
+func mapEnv(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
+
+// TestParseConfig tests comments, spacing and bare boolean options
+func TestParseConfig(t *testing.T) {
+	input := "# defaults\n\nbar-size = 50\n  refresh=250ms  \nquiet\n--on-complete = echo a=b # done\n"
+
+	file, err := parseConfig(strings.NewReader(input), "config")
+	if err != nil {
+		t.Fatalf("parseConfig() returned error: %v", err)
+	}
+	settings := file.settings
+
+	expected := []configSetting{
+		{"bar-size", "50", 3, ""},
+		{"refresh", "250ms", 4, ""},
+		{"quiet", "true", 5, ""},
+		{"on-complete", "echo a=b # done", 6, ""},
+	}
+	if len(settings) != len(expected) {
+		t.Fatalf("Expected %d settings, got %+v", len(expected), settings)
+	}
+	for i, s := range settings {
+		if s != expected[i] {
+			t.Errorf("Setting %d: expected %+v, got %+v", i, expected[i], s)
+		}
+	}
+
+	for input, expected := range map[string]string{
+		"= 5\n":                           "config:1: missing option name",
+		"[]\n":                            "config:1: missing profile name",
+		"[ci]\n[ci]\n":                    `config:2: duplicate profile "ci"`,
+		"[ci]\nquiet\nprofile = backup\n": "config:3: a profile can't select another profile",
+	} {
+		if _, err := parseConfig(strings.NewReader(input), "config"); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Parsing %q: expected error containing %q, got %v", input, expected, err)
+		}
+	}
+}
+
+// TestApplyDefaultsPrecedence tests that flags beat env, env beats the file and the file beats built-ins
+func TestApplyDefaultsPrecedence(t *testing.T) {
+	var cfg config
+	fs := newTestFlagSet(&cfg)
+	if err := fs.Parse([]string{"--bar-size=20"}); err != nil {
+		t.Fatal(err)
+	}
+
+	file := configFile{path: "config", settings: []configSetting{
+		{"bar-size", "50", 1, ""},
+		{"refresh", "250ms", 2, ""},
+		{"units", "si", 3, ""},
+	}}
+	env := mapEnv(map[string]string{
+		"PROGZER_BAR_SIZE": "60",
+		"PROGZER_UNITS":    "iec",
+		"PROGZER_LIMIT":    "1KiB",
+	})
+
+	sources, err := applyDefaults(fs, file, env)
+	if err != nil {
+		t.Fatalf("applyDefaults() returned error: %v", err)
+	}
+
+	if cfg.barSize != 20 || sources["bar-size"] != "flag" {
+		t.Errorf("Expected bar-size 20 from flag, got %d from %s", cfg.barSize, sources["bar-size"])
+	}
+	if cfg.units != "iec" || sources["units"] != "env PROGZER_UNITS" {
+		t.Errorf("Expected units iec from env, got %s from %s", cfg.units, sources["units"])
+	}
+	if cfg.refreshRate != 250*time.Millisecond || sources["refresh"] != "file config:2" {
+		t.Errorf("Expected refresh 250ms from file, got %s from %s", cfg.refreshRate, sources["refresh"])
+	}
+	if cfg.limit != 1024 || sources["limit"] != "env PROGZER_LIMIT" {
+		t.Errorf("Expected limit 1024 from env, got %d from %s", cfg.limit, sources["limit"])
+	}
+	if cfg.quiet || sources["quiet"] != "" {
+		t.Errorf("Expected quiet to keep its default, got %t from %s", cfg.quiet, sources["quiet"])
+	}
+}
+
+// TestApplyDefaultsErrors tests that bad settings name their origin
+func TestApplyDefaultsErrors(t *testing.T) {
+	tests := []struct {
+		settings []configSetting
+		env      map[string]string
+		expected string
+	}{
+		{[]configSetting{{"colour", "red", 4, ""}}, nil, `config:4: unknown option "colour"`},
+		{[]configSetting{{"colour", "red", 4, "ci"}}, nil, `config:4: unknown option "colour"`},
+		{[]configSetting{{"bar-size", "wide", 2, ""}}, nil, `config:2: invalid value "wide" for bar-size`},
+		{nil, map[string]string{"PROGZER_REFRESH": "soon"}, `invalid value "soon" for PROGZER_REFRESH`},
+		{nil, map[string]string{"PROGZER_PROFILE": "backup"}, `unknown profile "backup", no [backup] section in config`},
+	}
+
+	for _, test := range tests {
+		var cfg config
+		fs := newTestFlagSet(&cfg)
+		fs.Parse(nil)
+
+		file := configFile{path: "config", settings: test.settings}
+		_, err := applyDefaults(fs, file, mapEnv(test.env))
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Expected error containing %q, got %v", test.expected, err)
+		}
+	}
+}
+
+// TestPrintConfig tests the listing of values and sources
+func TestPrintConfig(t *testing.T) {
+	var cfg config
+	fs := newTestFlagSet(&cfg)
+	fs.Parse([]string{"--quiet"})
+	file := configFile{path: "config", settings: []configSetting{{"bar-size", "50", 1, ""}}}
+	sources, _ := applyDefaults(fs, file, mapEnv(nil))
+
+	var buf bytes.Buffer
+	printConfig(&buf, fs, sources)
+
+	for _, expected := range []string{
+		"bar-size  50     file config:1\n",
+		"quiet     true   flag\n",
+		"refresh   100ms  default\n",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, buf.String())
+		}
+	}
+}
+
+// TestPrintConfigError tests that a failed write is reported
+func TestPrintConfigError(t *testing.T) {
+	var cfg config
+	fs := newTestFlagSet(&cfg)
+	fs.Parse(nil)
+
+	if err := printConfig(newMockWriter(1, io.ErrClosedPipe), fs, nil); err == nil || !strings.Contains(err.Error(), "error printing config") {
+		t.Errorf("Expected a print error, got %v", err)
+	}
+}
+
+// TestApplyDefaultsCommandLineOnly tests that one-off actions are ignored outside the command line
+func TestApplyDefaultsCommandLineOnly(t *testing.T) {
+	var cfg config
+	fs := newTestFlagSet(&cfg)
+	fs.BoolVar(&cfg.showVersion, "version", false, "")
+	fs.StringVar(&cfg.getSizePath, "get-size", "", "")
+	fs.BoolVar(&cfg.printConfig, "print-config", false, "")
+	fs.Parse(nil)
+
+	file := configFile{path: "config", profiles: []string{"ci"}, settings: []configSetting{
+		{"version", "true", 1, ""},
+		{"get-size", "/etc/hosts", 2, ""},
+		{"print-config", "true", 4, "ci"},
+	}}
+	env := mapEnv(map[string]string{
+		"PROGZER_VERSION":      "true",
+		"PROGZER_GET_SIZE":     "/etc/hosts",
+		"PROGZER_PRINT_CONFIG": "true",
+		"PROGZER_PROFILE":      "ci",
+	})
+
+	sources, err := applyDefaults(fs, file, env)
+	if err != nil {
+		t.Fatalf("applyDefaults() returned error: %v", err)
+	}
+	if cfg.showVersion || cfg.getSizePath != "" || cfg.printConfig {
+		t.Errorf("Expected one-off actions to be ignored, got version %t get-size %q print-config %t", cfg.showVersion, cfg.getSizePath, cfg.printConfig)
+	}
+	for _, name := range []string{"version", "get-size", "print-config"} {
+		if sources[name] != "" {
+			t.Errorf("Expected no source for %s, got %s", name, sources[name])
+		}
+	}
+}
+
+// TestReadConfigFile tests reading the file from XDG_CONFIG_HOME and a missing file
+func TestReadConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	path := configPath()
+	if path != filepath.Join(dir, "progzer", "config") {
+		t.Fatalf("Unexpected config path %s", path)
+	}
+
+	if file, err := readConfigFile(path); err != nil || file.settings != nil {
+		t.Errorf("Expected a missing file to be ignored, got %+v, %v", file, err)
+	}
+
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, []byte("bar-size = 50\n"), 0644)
+	file, err := readConfigFile(path)
+	if err != nil || len(file.settings) != 1 || file.settings[0].value != "50" {
+		t.Errorf("Expected one setting, got %+v, %v", file, err)
+	}
+}
+
+// TestApplyDefaultsProfile tests that a profile overrides the top of the file but not env or flags
+func TestApplyDefaultsProfile(t *testing.T) {
+	input := "bar-size = 50\nunits = si\nrefresh = 1s\n\n[backup]\nbar-size = 80\nunits = iec\nrefresh = 5s\nquiet\n\n[ci]\nlimit = 1M\n"
+	file, err := parseConfig(strings.NewReader(input), "config")
+	if err != nil {
+		t.Fatalf("parseConfig() returned error: %v", err)
+	}
+	if len(file.profiles) != 2 || !file.hasProfile("backup") || !file.hasProfile("ci") {
+		t.Fatalf("Expected backup and ci profiles, got %v", file.profiles)
+	}
+
+	var cfg config
+	fs := newTestFlagSet(&cfg)
+	fs.Parse([]string{"--profile=backup", "--refresh=2s"})
+
+	sources, err := applyDefaults(fs, file, mapEnv(map[string]string{"PROGZER_UNITS": "bytes"}))
+	if err != nil {
+		t.Fatalf("applyDefaults() returned error: %v", err)
+	}
+
+	if cfg.barSize != 80 || sources["bar-size"] != "file config:6 [backup]" {
+		t.Errorf("Expected bar-size 80 from the profile, got %d from %s", cfg.barSize, sources["bar-size"])
+	}
+	if cfg.units != "bytes" || sources["units"] != "env PROGZER_UNITS" {
+		t.Errorf("Expected units from env over the profile, got %s from %s", cfg.units, sources["units"])
+	}
+	if cfg.refreshRate != 2*time.Second || sources["refresh"] != "flag" {
+		t.Errorf("Expected refresh from the flag over the profile, got %s from %s", cfg.refreshRate, sources["refresh"])
+	}
+	if !cfg.quiet || cfg.limit != 0 {
+		t.Errorf("Expected only the backup profile to apply, got quiet %t limit %d", cfg.quiet, cfg.limit)
+	}
+}
+
+// TestApplyDefaultsProfileFromFile tests selecting a profile at the top of the file
+func TestApplyDefaultsProfileFromFile(t *testing.T) {
+	file, _ := parseConfig(strings.NewReader("profile = ci\n[ci]\nlimit = 1M\n"), "config")
+
+	var cfg config
+	fs := newTestFlagSet(&cfg)
+	fs.Parse(nil)
+
+	if _, err := applyDefaults(fs, file, mapEnv(nil)); err != nil || cfg.limit != 1024*1024 {
+		t.Errorf("Expected the ci profile to set limit 1M, got %d, %v", cfg.limit, err)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// runHook runs a shell command with extra PROGZER_* environment variables.
+// The hook's output goes to stderr so it never mixes with the data stream.
+func runHook(command string, env []string) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// hookEnv describes the transfer so far as PROGZER_* environment variables
+func (p *Progress) hookEnv() []string {
+	s := p.summary()
+	st := p.Snapshot()
+
+	return []string{
+		"PROGZER_BYTES=" + strconv.FormatInt(s.Bytes, 10),
+		"PROGZER_TOTAL=" + strconv.FormatInt(st.TotalSize, 10),
+		"PROGZER_DURATION=" + strconv.FormatFloat(s.Duration, 'f', 3, 64),
+		"PROGZER_AVG_RATE=" + strconv.FormatInt(int64(s.AvgRate), 10),
+	}
+}
+
+// exitEnv extends hookEnv with the outcome of Process
+func (p *Progress) exitEnv(err error) []string {
+	env := append(p.hookEnv(),
+		"PROGZER_EXIT_REASON="+exitReason(err),
+		"PROGZER_EXIT_CODE="+strconv.Itoa(exitCode(err)),
+	)
+	if err != nil {
+		env = append(env, "PROGZER_ERROR="+err.Error())
+	}
+	return env
+}
+
+// exitReason names the outcome of Process for hooks
+func exitReason(err error) string {
+	switch {
+	case err == nil:
+		return "complete"
+	case errors.Is(err, errStalled):
+		return "stalled"
+	case errors.Is(err, errTooSlow):
+		return "too_slow"
+	}
+	if _, ok := interruptSignal(err); ok {
+		return "interrupted"
+	}
+	return "error"
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExitCodeAndReason tests mapping Process errors to exit codes and reasons
+func TestExitCodeAndReason(t *testing.T) {
+	tests := []struct {
+		err    error
+		code   int
+		reason string
+	}{
+		{nil, 0, "complete"},
+		{fmt.Errorf("%w: no data", errStalled), ExitStalled, "stalled"},
+		{fmt.Errorf("%w: slow", errTooSlow), ExitTooSlow, "too_slow"},
+		{errors.New("broken pipe"), 1, "error"},
+	}
+
+	for _, test := range tests {
+		if code := exitCode(test.err); code != test.code {
+			t.Errorf("exitCode(%v) = %d, expected %d", test.err, code, test.code)
+		}
+		if reason := exitReason(test.err); reason != test.reason {
+			t.Errorf("exitReason(%v) = %s, expected %s", test.err, reason, test.reason)
+		}
+	}
+}
+
+// TestExitEnv tests the environment passed to completion hooks
+func TestExitEnv(t *testing.T) {
+	p := &Progress{
+		totalSize: 8192,
+		startTime: time.Now().Add(-2 * time.Second),
+	}
+	p.bytesRead.Store(4096)
+
+	env := strings.Join(p.exitEnv(errors.New("broken pipe")), "\n")
+	expectedElements := []string{
+		"PROGZER_BYTES=4096",
+		"PROGZER_TOTAL=8192",
+		"PROGZER_DURATION=2.",
+		"PROGZER_AVG_RATE=",
+		"PROGZER_EXIT_REASON=error",
+		"PROGZER_EXIT_CODE=1",
+		"PROGZER_ERROR=broken pipe",
+	}
+	for _, expected := range expectedElements {
+		if !strings.Contains(env, expected) {
+			t.Errorf("Expected env to contain '%s', got '%s'", expected, env)
+		}
+	}
+
+	if env := strings.Join(p.exitEnv(nil), "\n"); strings.Contains(env, "PROGZER_ERROR") {
+		t.Errorf("Expected no PROGZER_ERROR on success, got '%s'", env)
+	}
+}
+
+// TestRunHook tests that hooks see the environment and keep stdout clean
+func TestRunHook(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "hook.out")
+
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	defer func() {
+		os.Stdout = oldStdout
+	}()
+
+	stderr := captureStderr(t, func() {
+		err := runHook("echo $PROGZER_EXIT_REASON > "+out+"; echo visible", []string{"PROGZER_EXIT_REASON=complete"})
+		if err != nil {
+			t.Errorf("runHook() returned error: %v", err)
+		}
+	})
+
+	w.Close()
+	stdout := make([]byte, 64)
+	n, _ := r.Read(stdout)
+	if n != 0 {
+		t.Errorf("Expected hook not to write to stdout, got '%s'", stdout[:n])
+	}
+	if !strings.Contains(stderr, "visible") {
+		t.Errorf("Expected hook output on stderr, got '%s'", stderr)
+	}
+
+	data, _ := os.ReadFile(out)
+	if strings.TrimSpace(string(data)) != "complete" {
+		t.Errorf("Expected hook to see PROGZER_EXIT_REASON=complete, got '%s'", data)
+	}
+
+	if err := runHook("exit 2", nil); err == nil {
+		t.Errorf("Expected error from failing hook")
+	}
+}
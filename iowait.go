@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// waitTimer accumulates the time spent blocked in one kind of call
+type waitTimer struct {
+	total atomic.Int64 // Nanoseconds spent in completed calls
+	since atomic.Int64 // Unix nanoseconds when the current call started, 0 if none
+}
+
+// begin marks the start of a call at now
+func (t *waitTimer) begin(now time.Time) {
+	t.since.Store(now.UnixNano())
+}
+
+// end adds the call started at start and finished at now to the total
+func (t *waitTimer) end(start, now time.Time) {
+	t.total.Add(int64(now.Sub(start)))
+	t.since.Store(0)
+}
+
+// elapsed returns the total including a call still in progress at now
+func (t *waitTimer) elapsed(now time.Time) time.Duration {
+	total := time.Duration(t.total.Load())
+	if since := t.since.Load(); since > 0 && now.UnixNano() > since {
+		total += time.Duration(now.UnixNano() - since)
+	}
+	return total
+}
+
+// timedReader counts the time spent in Read as waiting on input
+type timedReader struct {
+	r     io.Reader
+	timer *waitTimer
+	now   func() time.Time
+}
+
+func (t timedReader) Read(b []byte) (int, error) {
+	start := t.now()
+	t.timer.begin(start)
+	n, err := t.r.Read(b)
+	t.timer.end(start, t.now())
+	return n, err
+}
+
+// timedWriter counts the time spent in Write as waiting on output
+type timedWriter struct {
+	w     io.Writer
+	timer *waitTimer
+	now   func() time.Time
+}
+
+func (t timedWriter) Write(b []byte) (int, error) {
+	start := t.now()
+	t.timer.begin(start)
+	n, err := t.w.Write(b)
+	t.timer.end(start, t.now())
+	return n, err
+}
+
+// waitStats tracks how long the transfer waits on input versus output
+type waitStats struct {
+	input  waitTimer // Blocked reading stdin
+	output waitTimer // Blocked writing stdout
+
+	mu          sync.Mutex
+	lastTime    time.Time
+	lastInput   time.Duration
+	lastOutput  time.Duration
+	inputShare  float64 // Fraction of the last interval spent waiting on input
+	outputShare float64 // Fraction of the last interval spent waiting on output
+	measured    bool    // Whether any wait has been seen, false for file-to-file zero-copy
+}
+
+// start resets the interval at the beginning of a transfer
+func (w *waitStats) start(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.lastTime = now
+	w.lastInput = w.input.elapsed(now)
+	w.lastOutput = w.output.elapsed(now)
+	w.inputShare = 0
+	w.outputShare = 0
+}
+
+// sample computes the share of the interval since the previous sample spent waiting
+func (w *waitStats) sample(now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	interval := now.Sub(w.lastTime)
+	if interval <= 0 {
+		return
+	}
+
+	input, output := w.input.elapsed(now), w.output.elapsed(now)
+	w.inputShare = min(float64(input-w.lastInput)/float64(interval), 1)
+	w.outputShare = min(float64(output-w.lastOutput)/float64(interval), 1)
+	w.measured = w.measured || input > 0 || output > 0
+
+	w.lastTime = now
+	w.lastInput = input
+	w.lastOutput = output
+}
+
+// totals returns the overall time spent waiting on input and output up to now
+func (w *waitStats) totals(now time.Time) (time.Duration, time.Duration) {
+	return w.input.elapsed(now), w.output.elapsed(now)
+}
+
+// shares reports whether waits have been measured and the shares of the last interval
+func (w *waitStats) shares() (bool, float64, float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.measured, w.inputShare, w.outputShare
+}
+
+// waitText shows how much of the last interval was spent waiting on each side
+func waitText(s Snapshot) string {
+	if !s.waitKnown {
+		return ""
+	}
+	return fmt.Sprintf(" wait in %.0f%% out %.0f%%", s.inputWait*100, s.outputWait*100)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWaitTimerInProgress tests that a call still blocked counts towards the total
+func TestWaitTimerInProgress(t *testing.T) {
+	var timer waitTimer
+	timer.total.Store(int64(2 * time.Second))
+
+	start := time.Now()
+	timer.begin(start)
+	if got := timer.elapsed(start.Add(time.Second)); got != 3*time.Second {
+		t.Errorf("Expected 3s including the blocked call, got %s", got)
+	}
+
+	timer.end(start, start.Add(500*time.Millisecond))
+	if got := timer.elapsed(start.Add(time.Minute)); got != 2500*time.Millisecond || timer.since.Load() != 0 {
+		t.Errorf("Expected the finished call to be added to the total, got %s", got)
+	}
+}
+
+// TestWaitText tests the in-wait/out-wait indicator for the last interval
+func TestWaitText(t *testing.T) {
+	p := &Progress{totalSize: 1000, barSize: 10}
+	p.bytesRead.Store(500)
+	if text := waitText(p.Snapshot()); text != "" {
+		t.Errorf("Expected no indicator before any wait was measured, got '%s'", text)
+	}
+
+	start := time.Now()
+	p.waits.start(start)
+	p.waits.input.total.Store(int64(750 * time.Millisecond))
+	p.waits.output.total.Store(int64(100 * time.Millisecond))
+	p.waits.sample(start.Add(time.Second))
+
+	if bar := p.buildProgressBar(time.Second); !strings.Contains(bar, " wait in 75% out 10%") {
+		t.Errorf("Expected wait indicator in bar, got '%s'", bar)
+	}
+}
+
+// TestProcessInputWait tests that a slow producer shows up as input wait
+func TestProcessInputWait(t *testing.T) {
+	p := &Progress{
+		startTime:   time.Now(),
+		refreshRate: 10 * time.Millisecond,
+		quiet:       true,
+	}
+
+	_, err := runProcess(t, p, func(w *os.File) {
+		w.Write([]byte("first"))
+		time.Sleep(100 * time.Millisecond)
+		w.Write([]byte("second"))
+		w.Close()
+	})
+	if err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+
+	s := p.summary()
+	if s.InputWait < 0.09 || s.OutputWait >= s.InputWait {
+		t.Errorf("Expected mostly input wait, got input %.3fs output %.3fs", s.InputWait, s.OutputWait)
+	}
+
+	var buf bytes.Buffer
+	p.writeSummary(&buf, "human")
+	if !strings.Contains(buf.String(), "Waiting: input ") {
+		t.Errorf("Expected wait totals in summary, got '%s'", buf.String())
+	}
+}
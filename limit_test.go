@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runProcess runs Process with the given stdin pipe writer function and returns stdout
+func runProcess(t *testing.T, p *Progress, feed func(w *os.File)) ([]byte, error) {
+	t.Helper()
+
+	oldStdin := os.Stdin
+	oldStdout := os.Stdout
+	defer func() {
+		os.Stdin = oldStdin
+		os.Stdout = oldStdout
+	}()
+
+	r, w, _ := os.Pipe()
+	os.Stdin = r
+	outR, outW, _ := os.Pipe()
+	os.Stdout = outW
+
+	go feed(w)
+
+	output := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(outR)
+		output <- data
+	}()
+
+	err := p.Process(context.Background())
+	outW.Close()
+	return <-output, err
+}
+
+// TestProcessLimit tests stopping after --limit bytes
+func TestProcessLimit(t *testing.T) {
+	testData := bytes.Repeat([]byte("x"), 1000)
+	p := &Progress{
+		startTime:   time.Now(),
+		refreshRate: 100 * time.Millisecond,
+		quiet:       true,
+		limit:       300,
+	}
+
+	output, err := runProcess(t, p, func(w *os.File) {
+		w.Write(testData)
+		w.Close()
+	})
+	if err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+
+	if len(output) != 300 {
+		t.Errorf("Expected 300 bytes of output, got %d", len(output))
+	}
+	if s := p.summary(); s.Bytes != 300 || s.Stopped != "limit" || !s.Done {
+		t.Errorf("Expected summary stopped at limit after 300 bytes, got %+v", s)
+	}
+}
+
+// TestProcessLimitDrain tests that --drain consumes the rest of the input
+func TestProcessLimitDrain(t *testing.T) {
+	testData := bytes.Repeat([]byte("x"), 256*1024)
+	p := &Progress{
+		startTime:   time.Now(),
+		refreshRate: 100 * time.Millisecond,
+		quiet:       true,
+		limit:       1024,
+		drain:       true,
+	}
+
+	// The writer only finishes if all input is read
+	written := make(chan error, 1)
+	output, err := runProcess(t, p, func(w *os.File) {
+		_, err := w.Write(testData)
+		w.Close()
+		written <- err
+	})
+	if err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+
+	if writeErr := <-written; writeErr != nil {
+		t.Errorf("Expected upstream write to succeed with --drain, got %v", writeErr)
+	}
+	if len(output) != 1024 {
+		t.Errorf("Expected 1024 bytes of output, got %d", len(output))
+	}
+}
+
+// TestProcessMaxDuration tests stopping cleanly after the time budget
+func TestProcessMaxDuration(t *testing.T) {
+	p := &Progress{
+		startTime:   time.Now(),
+		refreshRate: 10 * time.Millisecond,
+		quiet:       true,
+		maxDuration: 50 * time.Millisecond,
+	}
+
+	opened := make(chan *os.File, 1)
+	output, err := runProcess(t, p, func(w *os.File) {
+		// Send some data and keep the pipe open
+		w.Write([]byte("partial"))
+		opened <- w
+	})
+	(<-opened).Close()
+
+	if err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+	if string(output) != "partial" {
+		t.Errorf("Expected buffered output to be flushed, got '%s'", output)
+	}
+	if s := p.summary(); s.Stopped != "max-duration" || !s.Done {
+		t.Errorf("Expected summary stopped at max duration, got %+v", s)
+	}
+}
+
+// TestNewProgressLimitAsTotal tests that the limit is used as total when the size is unknown
+func TestNewProgressLimitAsTotal(t *testing.T) {
+	if p := NewProgress(config{limit: 500, refreshRate: time.Second}); p.totalSize != 500 {
+		t.Errorf("Expected totalSize to be 500, got %d", p.totalSize)
+	}
+	if p := NewProgress(config{totalSize: 100, limit: 500, refreshRate: time.Second}); p.totalSize != 100 {
+		t.Errorf("Expected totalSize to be 100, got %d", p.totalSize)
+	}
+}
+
+// stuckWriter is an output that never drains, like a consumer that stopped reading
+type stuckWriter struct {
+	release chan struct{}
+}
+
+func (w stuckWriter) Write(b []byte) (int, error) {
+	<-w.release
+	return 0, io.ErrClosedPipe
+}
+
+// endlessReader is an input that always has more data, like yes
+type endlessReader struct{}
+
+func (endlessReader) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 'y'
+	}
+	return len(b), nil
+}
+
+// TestProcessStuckOutput tests that early stops don't wait for a blocked write
+func TestProcessStuckOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(p *Progress)
+		cancel     bool
+		expectErr  error
+		expectStop string
+	}{
+		{"max-duration", func(p *Progress) { p.maxDuration = 50 * time.Millisecond }, false, errOutputStuck, "max-duration"},
+		{"stall-timeout", func(p *Progress) { p.stallTimeout = 50 * time.Millisecond }, false, errStalled, ""},
+		{"interrupted", func(p *Progress) {}, true, context.Canceled, "interrupted"},
+		{"buffered", func(p *Progress) {
+			p.bufferSize = 256 * 1024
+			p.maxDuration = 50 * time.Millisecond
+		}, false, errOutputStuck, "max-duration"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			release := make(chan struct{})
+			defer close(release)
+
+			p := &Progress{
+				startTime:   time.Now(),
+				refreshRate: 10 * time.Millisecond,
+				quiet:       true,
+				input:       endlessReader{},
+				output:      stuckWriter{release},
+			}
+			test.setup(p)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.cancel {
+				time.AfterFunc(50*time.Millisecond, cancel)
+			}
+
+			start := time.Now()
+			err := p.Process(ctx)
+			if elapsed := time.Since(start); elapsed > 50*time.Millisecond+stopGrace+time.Second {
+				t.Errorf("Expected Process to stop promptly, took %s", elapsed)
+			}
+			if !errors.Is(err, test.expectErr) && err != test.expectErr {
+				t.Errorf("Expected error %v, got %v", test.expectErr, err)
+			}
+			if s := p.summary(); s.Stopped != test.expectStop || s.Done {
+				t.Errorf("Expected an unfinished transfer stopped by '%s', got %+v", test.expectStop, s)
+			}
+			if written := p.bytesRead.Load(); written > 64*1024 {
+				t.Errorf("Expected only bytes the output took to be counted, got %d", written)
+			}
+		})
+	}
+}
+
+// TestResumeLimitAsTotal tests that a limit used as the total starts from the resume point
+func TestResumeLimitAsTotal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output")
+	os.WriteFile(path, bytes.Repeat([]byte("x"), 400), 0644)
+
+	p := NewProgress(config{limit: 300, skipInput: 400, refreshRate: time.Second, barSize: 10})
+	p.input = bytes.NewReader(make([]byte, 1000))
+	if err := p.resume(path, 400); err != nil {
+		t.Fatalf("resume() returned error: %v", err)
+	}
+	defer p.output.(*os.File).Close()
+
+	if bar := p.buildProgressBar(time.Second); !strings.Contains(bar, "400B of 700B (57.1%)") {
+		t.Errorf("Expected bar towards 700B, got '%s'", bar)
+	}
+}
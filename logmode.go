@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// useLogMode decides whether to print plain log lines instead of a redrawn bar
+func useLogMode(cfg config) bool {
+	switch cfg.display {
+	case "log":
+		return true
+	case "bar", "none":
+		return false
+	default:
+		// Debug already prints one line per frame, so keep it as requested
+		return !cfg.debug && !isTerminal(os.Stderr)
+	}
+}
+
+// isTerminal reports whether the file is a character device such as a TTY
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// logProgress prints a timestamped line when the interval or percent step is reached
+func (p *Progress) logProgress(now time.Time, s Snapshot) {
+	due := p.logInterval > 0 && now.Sub(p.lastUpdate) >= p.logInterval
+	if p.logPercent > 0 && s.TotalSize > 0 {
+		if step := int(s.Percent / p.logPercent); step > p.lastLogStep {
+			p.lastLogStep = step
+			due = true
+		}
+	}
+	if !due {
+		return
+	}
+
+	p.lastUpdate = now
+	fmt.Fprintln(p.displayWriter(), formatLogLine(now, buildStatusText(s)))
+}
+
+// finalLabel names how the transfer ended for the closing line in log mode
+func finalLabel(err error) string {
+	switch exitReason(err) {
+	case "complete":
+		return "Done"
+	case "stalled":
+		return "Stalled"
+	case "too_slow":
+		return "Too slow"
+	case "interrupted":
+		return "Interrupted"
+	}
+	return "Failed"
+}
+
+// logFinal prints the closing line in log mode, labelled with how the transfer ended
+func (p *Progress) logFinal(label string) {
+	now := p.now()
+	s := p.snapshot(now)
+
+	text := fmt.Sprintf("%s: %s in %s @ %s",
+		label,
+		s.units.format(s.BytesRead),
+		formatDuration(s.Elapsed),
+		s.units.formatRate(s.Rate))
+	fmt.Fprintln(p.displayWriter(), formatLogLine(now, text))
+}
+
+// formatLogLine prefixes text with an RFC 3339 timestamp
+func formatLogLine(now time.Time, text string) string {
+	return fmt.Sprintf("[%s] %s", now.Format(time.RFC3339), strings.TrimSpace(text))
+}
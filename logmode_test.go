@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// captureStderr runs fn and returns what it wrote to stderr
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	defer func() {
+		os.Stderr = oldStderr
+	}()
+
+	fn()
+
+	w.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, r)
+	return buf.String()
+}
+
+// TestUseLogMode tests display mode selection
+func TestUseLogMode(t *testing.T) {
+	if !useLogMode(config{display: "log"}) {
+		t.Errorf("Expected log display to enable log mode")
+	}
+	if useLogMode(config{display: "bar"}) {
+		t.Errorf("Expected bar display to disable log mode")
+	}
+	if useLogMode(config{display: "none"}) {
+		t.Errorf("Expected none display to disable log mode")
+	}
+	if useLogMode(config{display: "auto", debug: true}) {
+		t.Errorf("Expected debug to keep per-frame output in auto mode")
+	}
+}
+
+// TestConfigValidate tests validation of display options
+func TestConfigValidate(t *testing.T) {
+	if err := (config{display: "auto"}).validate(); err != nil {
+		t.Errorf("Expected auto display to be valid, got %v", err)
+	}
+	if err := (config{display: "fancy"}).validate(); err == nil {
+		t.Errorf("Expected error for unknown display mode")
+	}
+	if err := (config{display: "log", logPercent: -5}).validate(); err == nil {
+		t.Errorf("Expected error for negative log-percent")
+	}
+}
+
+// TestLogProgressPercentSteps tests that lines are printed on percent steps only
+func TestLogProgressPercentSteps(t *testing.T) {
+	startTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := &Progress{
+		totalSize:  1000,
+		startTime:  startTime,
+		lastUpdate: startTime,
+		logMode:    true,
+		logPercent: 25,
+	}
+
+	output := captureStderr(t, func() {
+		for _, read := range []int64{100, 200, 250, 300, 600} {
+			p.bytesRead.Store(read)
+			now := startTime.Add(time.Second)
+			p.logProgress(now, p.snapshot(now))
+		}
+	})
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines (25%% and 50%%), got %d: %q", len(lines), output)
+	}
+	if !strings.HasPrefix(lines[0], "[2023-01-01T12:00:01Z] ") {
+		t.Errorf("Expected timestamp prefix, got '%s'", lines[0])
+	}
+	if !strings.Contains(lines[0], "(25.0%)") || !strings.Contains(lines[1], "(60.0%)") {
+		t.Errorf("Expected lines at 25.0%% and 60.0%%, got %q", lines)
+	}
+	if strings.Contains(output, "\r") {
+		t.Errorf("Expected no carriage returns in log mode, got %q", output)
+	}
+}
+
+// TestLogProgressInterval tests that lines are printed on the time interval
+func TestLogProgressInterval(t *testing.T) {
+	startTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := &Progress{
+		startTime:   startTime,
+		lastUpdate:  startTime,
+		logMode:     true,
+		logInterval: 10 * time.Second,
+	}
+	p.bytesRead.Store(1024)
+
+	output := captureStderr(t, func() {
+		for _, offset := range []time.Duration{2, 5, 10, 15, 20} {
+			now := startTime.Add(offset * time.Second)
+			p.logProgress(now, p.snapshot(now))
+		}
+	})
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines (at 10s and 20s), got %d: %q", len(lines), output)
+	}
+	if !strings.Contains(lines[0], "1.0KB @ ") {
+		t.Errorf("Expected status text in log line, got '%s'", lines[0])
+	}
+}
+
+// TestLogFinal tests the closing summary line
+func TestLogFinal(t *testing.T) {
+	p := &Progress{
+		startTime: time.Now().Add(-2 * time.Second),
+		logMode:   true,
+	}
+	p.bytesRead.Store(2048)
+
+	output := captureStderr(t, func() { p.logFinal("Done") })
+	if !strings.Contains(output, "] Done: 2.0KB in 2s @ ") {
+		t.Errorf("Expected final summary line, got '%s'", output)
+	}
+}
+
+// TestFinishDisplayLogFailure tests that every way a transfer ends gets a closing line
+func TestFinishDisplayLogFailure(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{nil, "] Done: "},
+		{fmt.Errorf("%w: no data for 5s", errStalled), "] Stalled: "},
+		{fmt.Errorf("%w: rate 1B/s", errTooSlow), "] Too slow: "},
+		{&interruptedError{syscall.SIGINT}, "] Interrupted: "},
+		{errors.New("error writing to stdout: broken pipe"), "] Failed: "},
+	}
+
+	for _, test := range tests {
+		var display bytes.Buffer
+		p := &Progress{startTime: time.Now(), logMode: true, display: &display}
+		p.finishDisplay(test.err)
+		if !strings.Contains(display.String(), test.expected) {
+			t.Errorf("Error %v: expected a line containing %q, got %q", test.err, test.expected, display.String())
+		}
+	}
+}
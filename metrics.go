@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// serveMetrics starts an HTTP server exposing progress metrics on addr
+func (p *Progress) serveMetrics(addr string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error starting metrics listener: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		p.writeMetrics(w)
+	})
+
+	server := &http.Server{
+		Addr:              listener.Addr().String(),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go server.Serve(listener)
+
+	return server, nil
+}
+
+// writeMetrics writes the current progress in Prometheus text format
+func (p *Progress) writeMetrics(w io.Writer) {
+	st := p.Snapshot()
+
+	writeMetric(w, "progzer_bytes_transferred_total", "counter", "Bytes passed from input to output.", float64(st.BytesRead))
+	writeMetric(w, "progzer_bytes_expected", "gauge", "Expected total size in bytes (0 when indeterminate).", float64(st.TotalSize))
+	writeMetric(w, "progzer_rate_bytes_per_second", "gauge", "Average transfer rate in bytes per second.", st.Rate)
+	writeMetric(w, "progzer_rate_bits_per_second", "gauge", "Average transfer rate in bits per second.", st.RateBits)
+	writeMetric(w, "progzer_eta_seconds", "gauge", "Estimated seconds remaining (-1 when unknown).", st.ETA)
+	writeMetric(w, "progzer_elapsed_seconds", "gauge", "Seconds since the transfer started.", st.Elapsed)
+}
+
+// writeMetric writes a single metric with its HELP and TYPE lines
+func writeMetric(w io.Writer, name, kind, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+	fmt.Fprintf(w, "%s %g\n", name, value)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWriteMetrics tests the Prometheus text output
+func TestWriteMetrics(t *testing.T) {
+	p := &Progress{
+		totalSize: 1000,
+		startTime: time.Now().Add(-10 * time.Second),
+	}
+	p.bytesRead.Store(500)
+
+	var buf bytes.Buffer
+	p.writeMetrics(&buf)
+	output := buf.String()
+
+	expectedElements := []string{
+		"# TYPE progzer_bytes_transferred_total counter",
+		"progzer_bytes_transferred_total 500\n",
+		"progzer_bytes_expected 1000\n",
+		"# TYPE progzer_rate_bytes_per_second gauge",
+		"# TYPE progzer_rate_bits_per_second gauge",
+		"progzer_eta_seconds ",
+		"progzer_elapsed_seconds ",
+	}
+
+	for _, expected := range expectedElements {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected metrics to contain '%s', got '%s'", expected, output)
+		}
+	}
+}
+
+// TestWriteMetricsIndeterminate tests that unknown values are reported as such
+func TestWriteMetricsIndeterminate(t *testing.T) {
+	p := &Progress{
+		totalSize: 0,
+		startTime: time.Now().Add(-1 * time.Second),
+	}
+	p.bytesRead.Store(1024)
+
+	var buf bytes.Buffer
+	p.writeMetrics(&buf)
+	output := buf.String()
+
+	for _, expected := range []string{"progzer_bytes_expected 0\n", "progzer_eta_seconds -1\n"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected metrics to contain '%s', got '%s'", expected, output)
+		}
+	}
+}
+
+// TestServeMetrics tests scraping metrics over HTTP
+func TestServeMetrics(t *testing.T) {
+	p := &Progress{
+		totalSize: 100,
+		startTime: time.Now(),
+	}
+	p.bytesRead.Store(42)
+
+	server, err := p.serveMetrics("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("serveMetrics() returned error: %v", err)
+	}
+	defer server.Close()
+
+	// The listener address is only known after serveMetrics binds it
+	addr := server.Addr
+	if addr == "" {
+		t.Fatalf("Expected server address to be set")
+	}
+
+	resp, err := http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to scrape metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+		t.Errorf("Expected text/plain content type, got %s", resp.Header.Get("Content-Type"))
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "progzer_bytes_transferred_total 42") {
+		t.Errorf("Expected scraped metrics to contain bytes transferred, got '%s'", body)
+	}
+}
+
+// TestServeMetricsInvalidAddress tests that listen errors are reported
+func TestServeMetricsInvalidAddress(t *testing.T) {
+	p := &Progress{startTime: time.Now()}
+
+	if _, err := p.serveMetrics("invalid-address"); err == nil {
+		t.Errorf("Expected error for invalid address")
+	}
+}
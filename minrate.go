@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// errTooSlow is returned by Process when --min-rate-abort is triggered
+var errTooSlow = errors.New("transfer too slow")
+
+// checkMinRate warns, runs the slow hook or aborts when the windowed rate drops below --min-rate
+func (p *Progress) checkMinRate() {
+	if p.minRate <= 0 || p.finished.Load() {
+		return
+	}
+
+	rate, ok := p.rates.windowRate()
+	if !ok {
+		return
+	}
+
+	// Only act when the state changes so a slow transfer warns once per episode
+	slow := rate < float64(p.minRate)
+	if p.slow.Swap(slow) == slow || !slow {
+		return
+	}
+
+	msg := fmt.Sprintf("rate %s below minimum %s over the last %s",
+		p.units.formatRate(rate), p.units.formatRate(float64(p.minRate)), formatDuration(p.minRateWindow.Seconds()))
+	p.warn(msg)
+
+	if p.onSlow != "" {
+		env := append(p.hookEnv(),
+			"PROGZER_RATE="+strconv.FormatInt(int64(rate), 10),
+			"PROGZER_MIN_RATE="+strconv.FormatInt(p.minRate, 10),
+		)
+		p.slowHooks.Add(1)
+		go func() {
+			defer p.slowHooks.Done()
+			if err := runHook(p.onSlow, env); err != nil {
+				p.warn(fmt.Sprintf("on-slow hook failed: %s", err))
+			}
+		}()
+	}
+
+	if p.minRateAbort {
+		p.abort(fmt.Errorf("%w: %s", errTooSlow, msg))
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWindowRate tests the rate over the sliding window
+func TestWindowRate(t *testing.T) {
+	startTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var r rateStats
+	r.start(startTime, 0, 10*time.Second)
+
+	r.record(startTime.Add(5*time.Second), 5000)
+	if _, ok := r.windowRate(); ok {
+		t.Errorf("Expected no window rate before a full window has passed")
+	}
+
+	r.record(startTime.Add(10*time.Second), 10000)
+	if rate, ok := r.windowRate(); !ok || rate != 1000 {
+		t.Errorf("Expected window rate 1000, got %f (ok=%v)", rate, ok)
+	}
+
+	// Old samples fall out of the window
+	r.record(startTime.Add(15*time.Second), 10500)
+	r.record(startTime.Add(20*time.Second), 11000)
+	if rate, ok := r.windowRate(); !ok || rate != 100 {
+		t.Errorf("Expected window rate 100, got %f (ok=%v)", rate, ok)
+	}
+}
+
+// newSlowProgress creates a Progress whose last window moved 100 bytes per second
+func newSlowProgress() *Progress {
+	startTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := &Progress{
+		startTime:     startTime,
+		barSize:       10,
+		minRate:       1024,
+		minRateWindow: 10 * time.Second,
+		aborted:       make(chan struct{}),
+	}
+	p.bytesRead.Store(1000)
+	p.rates.start(startTime, 0, p.minRateWindow)
+	p.rates.record(startTime.Add(10*time.Second), 1000)
+	return p
+}
+
+// TestCheckMinRateWarns tests that a slow transfer warns once and shows SLOW
+func TestCheckMinRateWarns(t *testing.T) {
+	p := newSlowProgress()
+
+	output := captureStderr(t, func() {
+		p.checkMinRate()
+		p.checkMinRate()
+	})
+
+	if strings.Count(output, "Warning: rate 100B/s below minimum 1.0KB/s") != 1 {
+		t.Errorf("Expected a single warning, got '%s'", output)
+	}
+	if bar := p.buildProgressBar(10 * time.Second); !strings.Contains(bar, " SLOW") {
+		t.Errorf("Expected bar to contain SLOW, got '%s'", bar)
+	}
+
+	select {
+	case <-p.aborted:
+		t.Errorf("Expected transfer not to be aborted without --min-rate-abort")
+	default:
+	}
+}
+
+// TestCheckMinRateAbort tests that --min-rate-abort stops the transfer
+func TestCheckMinRateAbort(t *testing.T) {
+	p := newSlowProgress()
+	p.minRateAbort = true
+
+	captureStderr(t, p.checkMinRate)
+
+	select {
+	case <-p.aborted:
+		if !errors.Is(p.abortErr, errTooSlow) {
+			t.Errorf("Expected too slow error, got %v", p.abortErr)
+		}
+	default:
+		t.Errorf("Expected transfer to be aborted")
+	}
+}
+
+// TestCheckMinRateHook tests that the slow hook runs with rate details
+func TestCheckMinRateHook(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "hook.out")
+	p := newSlowProgress()
+	p.onSlow = "echo $PROGZER_RATE $PROGZER_MIN_RATE > " + out
+
+	// The hook runs asynchronously
+	captureStderr(t, func() {
+		p.checkMinRate()
+		p.slowHooks.Wait()
+	})
+	data, _ := os.ReadFile(out)
+
+	if strings.TrimSpace(string(data)) != "100 1024" {
+		t.Errorf("Expected hook output '100 1024', got '%s'", data)
+	}
+}
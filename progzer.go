@@ -2,12 +2,17 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -16,22 +21,59 @@ import (
 const (
 	Version        = "0.2.0" // Version
 	DefaultBarSize = 34      // Default progress bar size in characters
+	ExitStalled    = 3       // Exit code when --stall-timeout aborts the transfer
+	ExitTooSlow    = 4       // Exit code when --min-rate-abort aborts the transfer
 )
 
 // Configuration options
 type config struct {
-	totalSize   int64
-	refreshRate time.Duration
-	quiet       bool
-	barSize     int
-	showVersion bool
-	debug       bool
-	getSizePath string
+	totalSize      int64
+	refreshRate    time.Duration
+	quiet          bool
+	barSize        int
+	showVersion    bool
+	debug          bool
+	getSizePath    string
+	metricsAddr    string
+	statusSock     string
+	statusFile     string
+	statusFmt      string
+	display        string
+	logInterval    time.Duration
+	logPercent     float64
+	summary        string
+	stallThreshold time.Duration
+	stallTimeout   time.Duration
+	minRate        int64
+	minRateWindow  time.Duration
+	minRateAbort   bool
+	onSlow         string
+	onComplete     string
+	onError        string
+	webhookURL     string
+	webhookStep    float64
+	webhookRetries int
+	webhookTimeout time.Duration
+	termProgress   bool
+	termTitle      bool
+	skipInput      int64
+	resumeOutput   string
+	limit          int64
+	drain          bool
+	maxDuration    time.Duration
+	zeroCopy       bool
+	bufferSize     int64
+	units          string
+	precision      int
+	rateUnit       string
+	profile        string
+	printConfig    bool
+	sources        map[string]string // Where each option was set: flag, env or file
 }
 
 // Progress holds the state of the progress bar
 type Progress struct {
-	bytesRead   int64
+	bytesRead   atomic.Int64
 	totalSize   int64
 	startTime   time.Time
 	lastUpdate  time.Time
@@ -39,16 +81,102 @@ type Progress struct {
 	quiet       bool
 	debug       bool
 	barSize     int
+	units       sizeUnits
+	finished    atomic.Bool
+	failure     atomic.Pointer[string] // Error that ended the transfer, nil unless it failed
+
+	statusFile   string
+	statusFormat string
+
+	hideBar     bool
+	logMode     bool
+	logInterval time.Duration
+	logPercent  float64
+	lastLogStep int
+
+	rates rateStats
+	waits waitStats
+
+	lastRead       atomic.Int64 // Unix nanoseconds of the last successful read
+	stallThreshold time.Duration
+	stallTimeout   time.Duration
+
+	minRate       int64
+	minRateWindow time.Duration
+	minRateAbort  bool
+	onSlow        string
+	slow          atomic.Bool
+	slowHooks     sync.WaitGroup // Running on-slow hooks
+
+	aborted   chan struct{}
+	abortErr  error
+	abortOnce sync.Once
+
+	webhook         *webhook
+	webhookStep     float64
+	lastWebhookStep int
+
+	termProgress bool
+	termTitle    bool
+
+	resumeOffset int64 // Bytes already transferred by a previous run
+
+	limit       int64
+	limitTotal  bool // totalSize is --limit because no --size was given
+	drain       bool
+	maxDuration time.Duration
+	interrupted atomic.Bool
+
+	stopMu        sync.Mutex // Guards outputStopped and stopReason; never held while writing
+	outputStopped bool
+	stopReason    string     // Why the transfer stopped before EOF: limit, max-duration or interrupted
+	writeMu       sync.Mutex // Held while using the buffered output writer
+
+	zeroCopy bool
+
+	bufferSize int64                      // Size of the ring buffer between reader and writer, 0 to disable
+	buffer     atomic.Pointer[ringBuffer] // Set while a buffered transfer runs
+
+	// Replaceable so tests and embedders can drive a transfer step by step
+	clock   Clock            // Source of the current time, the system clock if nil
+	ticks   <-chan time.Time // Triggers each refresh, a ticker at refreshRate if nil
+	input   io.Reader        // Source of the data, os.Stdin if nil
+	output  io.Writer        // Destination for the data, os.Stdout if nil
+	display io.Writer        // Where the bar, log lines and warnings go, os.Stderr if nil
 }
 
 func main() {
+	os.Exit(run())
+}
+
+// run transfers stdin to stdout and returns the exit code, so deferred
+// cleanup such as closing the status socket runs however the transfer ends
+func run() int {
 	// Parse command line flags
-	cfg := parseFlags()
+	cfg, err := parseFlags()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	// Show the effective options and exit if requested
+	if cfg.printConfig {
+		if err := printConfig(os.Stdout, flag.CommandLine, cfg.sources); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if err := cfg.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
 
 	// Show version and exit if requested
 	if cfg.showVersion {
 		fmt.Printf("version: %s\n", Version)
-		os.Exit(0)
+		return 0
 	}
 
 	// Get file size and exit if requested
@@ -56,184 +184,642 @@ func main() {
 		fileInfo, err := os.Stat(cfg.getSizePath)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-			os.Exit(1)
+			return 1
 		}
 		fmt.Printf("%d\n", fileInfo.Size())
-		os.Exit(0)
+		return 0
 	}
 
 	// Create a new progress bar
 	progress := NewProgress(cfg)
 
-	// Set up signal handling for graceful cleanup
-	c := make(chan os.Signal, 1)
+	// Print a one-off status line on SIGUSR1 (or SIGINFO), even with --quiet.
+	// The handler stays until exit, as the default action would kill us
+	// during a long skip or while the hooks run.
+	progress.notifyStatusSignals()
+
+	// Resume a previous transfer if requested
+	if cfg.resumeOutput != "" || cfg.skipInput > 0 {
+		if err := progress.resume(cfg.resumeOutput, cfg.skipInput); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			return 1
+		}
+	}
+
+	// Expose progress metrics if requested
+	if cfg.metricsAddr != "" {
+		server, err := progress.serveMetrics(cfg.metricsAddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			return 1
+		}
+		defer server.Close()
+	}
+
+	// Serve status snapshots over a unix socket if requested
+	if cfg.statusSock != "" {
+		server, err := progress.serveStatusSocket(cfg.statusSock)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			return 1
+		}
+		defer server.Close()
+	}
+
+	// Create the status file up front so path errors are reported immediately
+	if cfg.statusFile != "" {
+		if err := progress.writeStatusFile(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			return 1
+		}
+	}
+
+	// Notify the webhook that the transfer is starting
+	if cfg.webhookURL != "" {
+		progress.webhook = newWebhook(cfg.webhookURL, cfg.webhookTimeout, cfg.webhookRetries, progress.warn)
+		progress.webhook.send("start", progress.Snapshot(), nil)
+	}
+
+	// Cancel the transfer on SIGINT/SIGTERM so output is flushed before exiting;
+	// a second signal exits immediately in case flushing blocks
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	c := make(chan os.Signal, 2)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	go func() {
-		<-c
-		fmt.Fprintln(os.Stderr, "\nInterrupted")
-		os.Exit(1)
+		sig := <-c
+		cancel(&interruptedError{sig.(syscall.Signal)})
+		sig = <-c
+		os.Exit(exitCode(&interruptedError{sig.(syscall.Signal)}))
 	}()
 
 	// Process the data
-	if err := progress.Process(); err != nil {
+	err = progress.Process(ctx)
+	if output, ok := progress.output.(io.Closer); ok {
+		if closeErr := output.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("error closing output: %w", closeErr)
+		}
+	}
+
+	// Report what was transferred, even if the transfer failed, and always
+	// show how far an interrupted transfer got
+	summary := cfg.summary
+	if _, interrupted := interruptSignal(err); interrupted && summary == "" && !cfg.quiet {
+		summary = "human"
+	}
+	if summary != "" {
+		progress.writeSummary(os.Stderr, summary)
+	}
+
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-		os.Exit(1)
 	}
+
+	// Deliver the final webhook event before exiting
+	if progress.webhook != nil {
+		event := "complete"
+		if err != nil {
+			event = "error"
+		}
+		progress.webhook.send(event, progress.Snapshot(), err)
+		progress.webhook.Close()
+	}
+
+	// Run the completion or failure hook once any on-slow hook has finished
+	progress.slowHooks.Wait()
+	hook := cfg.onComplete
+	if err != nil {
+		hook = cfg.onError
+	}
+	if hook != "" {
+		if hookErr := runHook(hook, progress.exitEnv(err)); hookErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: hook failed: %s\n", hookErr)
+		}
+	}
+
+	return exitCode(err)
+}
+
+// exitCode maps a Process error to the process exit code
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errStalled):
+		return ExitStalled
+	case errors.Is(err, errTooSlow):
+		return ExitTooSlow
+	}
+	if sig, ok := interruptSignal(err); ok {
+		return 128 + int(sig) // Shell convention: 130 for SIGINT, 143 for SIGTERM
+	}
+	return 1
 }
 
-// Parse command line flags
-func parseFlags() config {
+// Parse command line flags, taking defaults from the config file and environment
+func parseFlags() (config, error) {
 	var cfg config
-	flag.Int64Var(&cfg.totalSize, "size", 0, "Expected total size in bytes (default: indeterminate)")
+	sizeVar(&cfg.totalSize, "size", "Expected total size as `SIZE`, e.g. 1.5GiB or 2TB (default: indeterminate)")
 	flag.DurationVar(&cfg.refreshRate, "refresh", 100*time.Millisecond, "Refresh rate for progress updates")
 	flag.BoolVar(&cfg.quiet, "quiet", false, "Don't show progress bar")
 	flag.IntVar(&cfg.barSize, "bar-size", DefaultBarSize, "Size of the progress bar in characters")
 	flag.BoolVar(&cfg.showVersion, "version", false, "Show version information and exit")
 	flag.BoolVar(&cfg.debug, "debug", false, "Debug show each progress on new line")
 	flag.StringVar(&cfg.getSizePath, "get-size", "", "Get size of the specified file in bytes and exit")
+	flag.StringVar(&cfg.metricsAddr, "metrics-listen", "", "Serve Prometheus metrics on the given address (e.g. 127.0.0.1:9123)")
+	flag.StringVar(&cfg.statusSock, "status-socket", "", "Serve JSON status snapshots on the given unix socket path")
+	flag.StringVar(&cfg.statusFile, "status-file", "", "Atomically rewrite the given file with the latest status on each refresh")
+	flag.StringVar(&cfg.statusFmt, "status-format", "json", "Format of the status file: json or kv (key=value lines)")
+	flag.StringVar(&cfg.display, "display", "auto", "Display mode: bar, log (timestamped lines), none, or auto (log when stderr is not a terminal)")
+	flag.DurationVar(&cfg.logInterval, "log-interval", 10*time.Second, "In log mode, print a line at least this often (0 to disable)")
+	flag.Float64Var(&cfg.logPercent, "log-percent", 10, "In log mode, print a line every N percent when the size is known (0 to disable)")
+	flag.StringVar(&cfg.summary, "summary", "", "Print a transfer summary at the end: human or json")
+	flag.DurationVar(&cfg.stallThreshold, "stall-threshold", 10*time.Second, "Show a STALLED indicator after no data for this long (0 to disable)")
+	flag.DurationVar(&cfg.stallTimeout, "stall-timeout", 0, "Abort with exit code 3 after no data for this long (0 to disable)")
+	sizeVar(&cfg.minRate, "min-rate", "Warn when the rate stays below `SIZE` per second, e.g. 5M (default: disabled)")
+	flag.DurationVar(&cfg.minRateWindow, "min-rate-window", 60*time.Second, "Window over which the rate must stay below --min-rate")
+	flag.BoolVar(&cfg.minRateAbort, "min-rate-abort", false, "Abort with exit code 4 when the rate stays below --min-rate")
+	flag.StringVar(&cfg.onSlow, "on-slow", "", "Shell command to run when the rate drops below --min-rate")
+	flag.StringVar(&cfg.onComplete, "on-complete", "", "Shell command to run after a successful transfer")
+	flag.StringVar(&cfg.onError, "on-error", "", "Shell command to run after a failed transfer")
+	flag.StringVar(&cfg.webhookURL, "webhook", "", "POST JSON events to this URL at start, milestones and finish")
+	flag.Float64Var(&cfg.webhookStep, "webhook-step", 10, "Send a webhook progress event every N percent when the size is known (0 to disable)")
+	flag.IntVar(&cfg.webhookRetries, "webhook-retries", 3, "Retries for a failed webhook delivery")
+	flag.DurationVar(&cfg.webhookTimeout, "webhook-timeout", 10*time.Second, "Timeout for each webhook request")
+	flag.BoolVar(&cfg.termProgress, "term-progress", false, "Report progress to the terminal taskbar/tab with OSC 9;4")
+	flag.BoolVar(&cfg.termTitle, "term-title", false, "Show progress in the terminal window title")
+	sizeVar(&cfg.skipInput, "skip-input", "Discard the first `SIZE` bytes of input, e.g. 10G")
+	flag.StringVar(&cfg.resumeOutput, "resume-output", "", "Append to an existing output file instead of stdout, skipping as much input as it already holds")
+	sizeVar(&cfg.limit, "limit", "Pass through at most `SIZE` bytes and then stop, e.g. 10G")
+	flag.BoolVar(&cfg.drain, "drain", false, "After --limit, keep reading and discarding input instead of closing it")
+	flag.DurationVar(&cfg.maxDuration, "max-duration", 0, "Stop the transfer cleanly after this long (0 to disable)")
+	flag.BoolVar(&cfg.zeroCopy, "zero-copy", true, "On Linux, copy with splice/copy_file_range when stdin and stdout allow it")
+	sizeVar(&cfg.bufferSize, "buffer-size", "Buffer up to `SIZE` bytes between reading and writing, e.g. 256M (0 to disable)")
+	flag.StringVar(&cfg.units, "units", "", "Units for sizes and rates: iec (KiB, MiB), si (kB, MB) or bytes (default: powers of 1024 labelled KB, MB)")
+	flag.StringVar(&cfg.rateUnit, "rate-unit", "bytes", "Show rates in bytes (per second, like sizes) or bits (Kbit/s, Mbit/s, Gbit/s)")
+	flag.IntVar(&cfg.precision, "precision", -1, "Decimal places for sizes and rates, -1 for 1 on kilobytes and 2 above")
+	flag.StringVar(&cfg.profile, "profile", "", "Apply the options in this [profile] section of the config file")
+	flag.BoolVar(&cfg.printConfig, "print-config", false, "Print each option's effective value and where it came from, then exit")
 	flag.Parse()
 
-	return cfg
+	file, err := readConfigFile(configPath())
+	if err != nil {
+		return cfg, err
+	}
+	cfg.sources, err = applyDefaults(flag.CommandLine, file, os.LookupEnv)
+	if err != nil {
+		return cfg, err
+	}
+	return cfg, applySizeUnits(flag.CommandLine, cfg.units)
+}
+
+// validate checks option values that flag parsing can't
+func (cfg config) validate() error {
+	switch cfg.display {
+	case "", "auto", "bar", "log", "none":
+	default:
+		return fmt.Errorf("unknown display mode: %s", cfg.display)
+	}
+	if cfg.logPercent < 0 {
+		return fmt.Errorf("log-percent must not be negative")
+	}
+	if cfg.maxDuration < 0 {
+		return fmt.Errorf("max-duration must not be negative")
+	}
+	if cfg.minRate > 0 && cfg.minRateWindow <= 0 {
+		return fmt.Errorf("min-rate-window must be positive")
+	}
+	if cfg.webhookStep < 0 || cfg.webhookRetries < 0 {
+		return fmt.Errorf("webhook-step and webhook-retries must not be negative")
+	}
+	if cfg.bufferSize > math.MaxInt32 {
+		return fmt.Errorf("buffer-size must be at most 2GB")
+	}
+	if !validUnits(cfg.units) {
+		return fmt.Errorf("unknown units: %s", cfg.units)
+	}
+	switch cfg.rateUnit {
+	case "", "bytes", "bits":
+	default:
+		return fmt.Errorf("unknown rate unit: %s", cfg.rateUnit)
+	}
+	if cfg.precision > 9 {
+		return fmt.Errorf("precision must be at most 9")
+	}
+	switch cfg.summary {
+	case "", "human", "json":
+	default:
+		return fmt.Errorf("unknown summary format: %s", cfg.summary)
+	}
+	return nil
 }
 
 // NewProgress creates a new progress bar
 func NewProgress(cfg config) *Progress {
+	// Without a known size, show progress towards the limit
+	totalSize := cfg.totalSize
+	limitTotal := totalSize <= 0 && cfg.limit > 0
+	if limitTotal {
+		totalSize = cfg.limit
+	}
+
+	units := newSizeUnits(cfg.units, cfg.precision)
+	units.rateBits = cfg.rateUnit == "bits"
+
 	return &Progress{
-		bytesRead:   0,
-		totalSize:   cfg.totalSize,
+		totalSize:   totalSize,
 		startTime:   time.Now(),
 		lastUpdate:  time.Now().Add(-1 * time.Hour), // Force initial update
 		refreshRate: cfg.refreshRate,
 		quiet:       cfg.quiet,
 		debug:       cfg.debug,
 		barSize:     cfg.barSize,
+		units:       units,
+
+		statusFile:   cfg.statusFile,
+		statusFormat: cfg.statusFmt,
+
+		hideBar:     cfg.display == "none",
+		logMode:     useLogMode(cfg),
+		logInterval: cfg.logInterval,
+		logPercent:  cfg.logPercent,
+
+		stallThreshold: cfg.stallThreshold,
+		stallTimeout:   cfg.stallTimeout,
+
+		minRate:       cfg.minRate,
+		minRateWindow: cfg.minRateWindow,
+		minRateAbort:  cfg.minRateAbort,
+		onSlow:        cfg.onSlow,
+
+		webhookStep: cfg.webhookStep,
+
+		// Escape sequences are only useful when stderr is the terminal
+		termProgress: cfg.termProgress && isTerminal(os.Stderr),
+		termTitle:    cfg.termTitle && isTerminal(os.Stderr),
+
+		limit:       cfg.limit,
+		limitTotal:  limitTotal,
+		drain:       cfg.drain,
+		maxDuration: cfg.maxDuration,
+
+		zeroCopy: cfg.zeroCopy,
+
+		bufferSize: cfg.bufferSize,
 	}
 }
 
 // Process reads from stdin and writes to stdout while tracking progress
-func (p *Progress) Process() error {
+func (p *Progress) Process(ctx context.Context) error {
 	// Use a larger buffer for better performance
-	reader := bufio.NewReaderSize(os.Stdin, 64*1024)
-	writer := bufio.NewWriterSize(os.Stdout, 64*1024)
-	defer writer.Flush()
-
-	buffer := make([]byte, 64*1024)
-	ticker := time.NewTicker(p.refreshRate)
-	defer ticker.Stop()
+	input := p.inputReader()
+	output := p.outputWriter()
+
+	// Time the underlying reads and writes to tell which side is holding up the transfer
+	reader := bufio.NewReaderSize(timedReader{input, &p.waits.input, p.now}, 64*1024)
+	writer := bufio.NewWriterSize(timedWriter{output, &p.waits.output, p.now}, 64*1024)
+
+	ticks := p.ticks
+	if ticks == nil {
+		ticker := time.NewTicker(p.refreshRate)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
 
-	// Create a done channel for the ticker routine
+	// Create a done channel for the ticker routine, which is stopped before
+	// the final frame so the two never draw at the same time
 	done := make(chan struct{})
-	defer close(done)
+	refreshed := make(chan struct{})
+	stopRefresh := sync.OnceFunc(func() {
+		close(done)
+		<-refreshed
+	})
+	defer stopRefresh()
+
+	// Take over the terminal title and taskbar progress while running
+	if p.termProgress || p.termTitle {
+		p.startTerminal()
+		defer p.resetTerminal()
+	}
 
-	// Update the progress bar in the background
-	if !p.quiet {
-		go func() {
-			for {
-				select {
-				case <-ticker.C:
-					p.updateDisplay()
-				case <-done:
+	// Sample rates and update the progress bar and status file in the background
+	p.rates.start(p.startTime, p.resumeOffset, p.minRateWindow)
+	p.waits.start(p.startTime)
+	p.aborted = make(chan struct{})
+	go func() {
+		defer close(refreshed)
+		for {
+			select {
+			case <-ticks:
+				p.refresh()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// Copy in the background so a stalled or slow transfer can be abandoned
+	result := make(chan error, 1)
+	go func() {
+		if p.bufferSize > 0 {
+			// Decouple reading from writing so each side only waits when the buffer is full or empty
+			result <- p.transferBuffered(reader, writer)
+			return
+		}
+		if p.zeroCopy {
+			// Move data inside the kernel when both ends are files that support it
+			src, srcIsFile := input.(*os.File)
+			dst, dstIsFile := output.(*os.File)
+			if srcIsFile && dstIsFile {
+				if handled, err := p.transferZeroCopy(src, dst, writer); handled {
+					result <- err
 					return
 				}
 			}
-		}()
+		}
+		result <- p.transfer(reader, writer)
+	}()
+
+	// Stop cleanly once the time budget is used up
+	var budget <-chan time.Time
+	if p.maxDuration > 0 {
+		timer := time.NewTimer(p.maxDuration)
+		defer timer.Stop()
+		budget = timer.C
+	}
+
+	var err error
+	select {
+	case err = <-result:
+	case <-budget:
+		if _, err = p.abandonOutput(writer, "max-duration"); err == nil {
+			p.finished.Store(true)
+		}
+	case <-p.aborted:
+		p.abandonOutput(writer, "")
+		err = p.abortErr
+	case <-ctx.Done():
+		// Keep what was transferred and mark the last frame as interrupted
+		p.interrupted.Store(true)
+		p.abandonOutput(writer, "interrupted")
+		err = context.Cause(ctx)
+	}
+	if err != nil {
+		msg := err.Error()
+		p.failure.Store(&msg)
+	}
+
+	// Draw the final frame, showing 100% on success, and leave the final
+	// status behind however the transfer ended
+	stopRefresh()
+	p.finishDisplay(err)
+	if p.statusFile != "" {
+		if statusErr := p.writeStatusFile(); statusErr != nil && err == nil {
+			return statusErr
+		}
+	}
+
+	return err
+}
+
+// inputReader returns the configured input, or stdin
+func (p *Progress) inputReader() io.Reader {
+	if p.input == nil {
+		return os.Stdin
+	}
+	return p.input
+}
+
+// outputWriter returns the configured output, or stdout
+func (p *Progress) outputWriter() io.Writer {
+	if p.output == nil {
+		return os.Stdout
+	}
+	return p.output
+}
+
+// displayWriter returns where the progress display goes, or stderr
+func (p *Progress) displayWriter() io.Writer {
+	if p.display == nil {
+		return os.Stderr
+	}
+	return p.display
+}
+
+// transfer runs the main read/write loop until EOF or --limit
+func (p *Progress) transfer(reader io.Reader, writer *bufio.Writer) error {
+	buffer := make([]byte, 64*1024)
+
+	input := reader
+	if p.limit > 0 {
+		input = io.LimitReader(reader, p.limit)
 	}
 
-	// Main read/write loop
 	for {
-		n, err := reader.Read(buffer)
+		n, err := input.Read(buffer)
 		if n > 0 {
-			p.bytesRead += int64(n)
-			if _, writeErr := writer.Write(buffer[:n]); writeErr != nil {
-				return fmt.Errorf("error writing to stdout: %w", writeErr)
+			p.lastRead.Store(p.now().UnixNano())
+
+			// Writes are serialized with the final flush so nothing is written after a stop
+			p.writeMu.Lock()
+			if p.stopped() {
+				p.writeMu.Unlock()
+				return nil
+			}
+			if _, err := writer.Write(buffer[:n]); err != nil {
+				p.writeMu.Unlock()
+				return fmt.Errorf("error writing to stdout: %w", err)
 			}
 
-			// Flush periodically to ensure data flows through the pipe
-			if p.bytesRead%int64(1024*1024) == 0 {
+			// Count only what the output took, then flush periodically to ensure data flows through the pipe
+			if p.bytesRead.Add(int64(n))%int64(1024*1024) == 0 {
 				if flushErr := writer.Flush(); flushErr != nil {
+					p.writeMu.Unlock()
 					return fmt.Errorf("error flushing output: %w", flushErr)
 				}
 			}
+			p.writeMu.Unlock()
 		}
 
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
+			p.stopOutput(writer, "")
 			return fmt.Errorf("error reading from stdin: %w", err)
 		}
 	}
 
-	// Ensure final update shows 100%
-	if !p.quiet {
-		p.updateDisplay()
-		fmt.Fprintln(os.Stderr, "") // Final newline
+	return p.finishTransfer(reader, writer)
+}
+
+// finishTransfer flushes the output after EOF or --limit and optionally drains the input
+func (p *Progress) finishTransfer(reader io.Reader, writer *bufio.Writer) error {
+	var reason string
+	if p.limit > 0 && p.bytesRead.Load()-p.resumeOffset >= p.limit {
+		reason = "limit"
+	}
+
+	stopped, err := p.stopOutput(writer, reason)
+	if err != nil || !stopped {
+		return err
+	}
+	p.rates.record(p.now(), p.bytesRead.Load())
+	p.finished.Store(true)
+
+	// Keep reading so the upstream command doesn't get SIGPIPE
+	if reason == "limit" && p.drain {
+		if _, err := io.Copy(io.Discard, reader); err != nil {
+			return fmt.Errorf("error draining stdin: %w", err)
+		}
 	}
 
 	return nil
 }
 
-// updateDisplay updates the progress display
-func (p *Progress) updateDisplay() {
-	now := time.Now()
-	elapsed := now.Sub(p.startTime)
+// stopGrace bounds how long an early stop waits for a stuck output to take
+// the data already read
+const stopGrace = 500 * time.Millisecond
 
-	// Build progress bar
-	bar := p.buildProgressBar(elapsed)
+// errOutputStuck reports that an early stop gave up on an output that
+// stopped taking data, so part of what was counted never reached it
+var errOutputStuck = errors.New("output stopped accepting data")
 
-	// Print the bar
-	if p.debug {
-		fmt.Fprint(os.Stderr, bar+"\n")
-	} else {
-		fmt.Fprint(os.Stderr, "\r"+bar)
+// markStopped prevents any further writes, recording reason. It reports
+// whether this call stopped the output.
+func (p *Progress) markStopped(reason string) bool {
+	p.stopMu.Lock()
+	defer p.stopMu.Unlock()
+
+	if p.outputStopped {
+		return false
 	}
+	p.outputStopped = true
+	p.stopReason = reason
+	return true
 }
 
-// buildProgressBar creates the progress bar string
-func (p *Progress) buildProgressBar(elapsed time.Duration) string {
-	// Calculate percentages and rates
-	percentComplete := 0.0
-	if p.totalSize > 0 {
-		percentComplete = float64(p.bytesRead) / float64(p.totalSize) * 100.0
-		if percentComplete > 100.0 {
-			percentComplete = 100.0
+// stopped reports whether the output has been stopped
+func (p *Progress) stopped() bool {
+	p.stopMu.Lock()
+	defer p.stopMu.Unlock()
+	return p.outputStopped
+}
+
+// stopOutput flushes buffered output and prevents any further writes.
+// It reports whether this call stopped the output, recording reason if so.
+// Only the transfer goroutine calls it, so it waits for a slow consumer.
+func (p *Progress) stopOutput(writer *bufio.Writer, reason string) (bool, error) {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	if !p.markStopped(reason) {
+		return false, nil
+	}
+	if err := writer.Flush(); err != nil {
+		return true, fmt.Errorf("error flushing output: %w", err)
+	}
+	return true, nil
+}
+
+// abandonOutput stops the output when Process cuts the transfer short. The
+// transfer goroutine may be blocked writing to a consumer that stopped
+// reading, so this waits at most stopGrace for that write and the final
+// flush, and fails with errOutputStuck if the output won't take it by then.
+func (p *Progress) abandonOutput(writer *bufio.Writer, reason string) (bool, error) {
+	if !p.markStopped(reason) {
+		return false, nil
+	}
+
+	flushed := make(chan error, 1)
+	go func() {
+		p.writeMu.Lock()
+		defer p.writeMu.Unlock()
+		flushed <- writer.Flush()
+	}()
+
+	timer := time.NewTimer(stopGrace)
+	defer timer.Stop()
+	select {
+	case err := <-flushed:
+		if err != nil {
+			return true, fmt.Errorf("error flushing output: %w", err)
 		}
+	case <-timer.C:
+		return true, fmt.Errorf("error flushing output: %w after %s", errOutputStuck, stopGrace)
 	}
+	return true, nil
+}
 
-	// Calculate transfer rate
-	bytesPerSec := float64(p.bytesRead) / max(elapsed.Seconds(), 0.001)
+// refresh samples the rate, redraws the progress bar and rewrites the status file
+func (p *Progress) refresh() {
+	now := p.now()
+	p.rates.record(now, p.bytesRead.Load())
+	p.waits.sample(now)
+	p.checkStall(now)
+	p.checkMinRate()
+	p.checkWebhookMilestone()
+	if !p.quiet {
+		p.updateDisplay()
+	}
+	if p.statusFile != "" {
+		// A failed rewrite keeps the previous status; the final write reports errors
+		p.writeStatusFile()
+	}
+}
 
-	// Format strings
-	var completionStr string
-	if p.totalSize > 0 {
-		completionStr = fmt.Sprintf("%.1f%%", percentComplete)
-	} else {
-		completionStr = "---"
+// finishDisplay draws the last frame and moves past the progress line, or
+// in log mode prints a closing line saying how the transfer ended
+func (p *Progress) finishDisplay(err error) {
+	if p.quiet {
+		return
 	}
 
-	readStr := formatSize(p.bytesRead)
-	totalStr := formatSize(p.totalSize)
-	rateStr := fmt.Sprintf("%s/s", formatSize(int64(bytesPerSec)))
+	switch {
+	case p.logMode:
+		p.logFinal(finalLabel(err))
+	case !p.hideBar:
+		p.updateDisplay()
+		fmt.Fprintln(p.displayWriter(), "") // Final newline
+	}
+}
 
-	// Calculate estimated time remaining
-	var etaStr string
-	if p.totalSize > 0 && p.bytesRead > 0 && bytesPerSec > 0 {
-		bytesRemaining := p.totalSize - p.bytesRead
-		if bytesRemaining > 0 {
-			secondsRemaining := float64(bytesRemaining) / bytesPerSec
-			etaStr = fmt.Sprintf(" ETA: %s", formatDuration(secondsRemaining))
-		} else {
-			etaStr = " Done!"
-		}
+// updateDisplay updates the progress display
+func (p *Progress) updateDisplay() {
+	now := p.now()
+	s := p.snapshot(now)
+
+	// Report progress to the terminal itself
+	p.updateTerminal(s)
+	if p.hideBar {
+		return
 	}
 
-	// Build status text
-	var statusText string
-	if p.totalSize > 0 {
-		statusText = fmt.Sprintf("%s of %s (%s) @ %s%s", readStr, totalStr, completionStr, rateStr, etaStr)
+	// Print periodic plain lines instead of redrawing
+	if p.logMode {
+		p.logProgress(now, s)
+		return
+	}
+
+	// Build progress bar
+	bar := p.renderBar(s)
+
+	// Print the bar
+	if p.debug {
+		fmt.Fprint(p.displayWriter(), bar+"\n")
 	} else {
-		statusText = fmt.Sprintf("%s @ %s   ", readStr, rateStr)
+		fmt.Fprint(p.displayWriter(), "\r"+bar)
 	}
+}
+
+// buildProgressBar creates the progress bar string for the given time since start
+func (p *Progress) buildProgressBar(elapsed time.Duration) string {
+	return p.renderBar(p.snapshot(p.startTime.Add(elapsed)))
+}
+
+// renderBar creates the progress bar string from a snapshot
+func (p *Progress) renderBar(s Snapshot) string {
+	statusText := buildStatusText(s)
 
 	// Use fixed bar size
 	barWidth := p.barSize
@@ -242,9 +828,9 @@ func (p *Progress) buildProgressBar(elapsed time.Duration) string {
 	var bar strings.Builder
 	bar.WriteString("[")
 
-	if p.totalSize > 0 {
+	if s.TotalSize > 0 {
 		// Known size mode
-		completedWidth := int(float64(barWidth) * percentComplete / 100.0)
+		completedWidth := int(float64(barWidth) * s.Percent / 100.0)
 		if completedWidth > barWidth {
 			completedWidth = barWidth
 		}
@@ -261,7 +847,7 @@ func (p *Progress) buildProgressBar(elapsed time.Duration) string {
 		}
 	} else {
 		// Indeterminate mode - show a moving block
-		position := int(elapsed.Milliseconds()/100) % (barWidth * 2)
+		position := int(s.elapsed.Milliseconds()/100) % (barWidth * 2)
 		symbol := "=>"
 
 		if position >= barWidth {
@@ -285,21 +871,96 @@ func (p *Progress) buildProgressBar(elapsed time.Duration) string {
 	return bar.String()
 }
 
-// formatSize formats bytes to human-readable string
-func formatSize(bytes int64) string {
-	if bytes < 0 {
-		return "?"
+// buildStatusText creates the size, rate and ETA text shown after the bar
+func buildStatusText(s Snapshot) string {
+	// Format strings
+	var completionStr string
+	if s.TotalSize > 0 {
+		completionStr = fmt.Sprintf("%.1f%%", s.Percent)
+	} else {
+		completionStr = "---"
 	}
-	if bytes < 1024 {
-		return fmt.Sprintf("%dB", bytes)
+
+	readStr := s.units.format(s.BytesRead)
+	totalStr := s.units.format(s.TotalSize)
+	rateStr := s.units.formatRate(s.Rate)
+
+	// Show the estimated time remaining
+	var etaStr string
+	if s.TotalSize > 0 && s.BytesRead > 0 && s.Rate > 0 {
+		if s.ETA > 0 {
+			etaStr = fmt.Sprintf(" ETA: %s", formatDuration(s.ETA))
+		} else {
+			etaStr = " Done!"
+		}
 	}
-	if bytes < 1024*1024 {
-		return fmt.Sprintf("%.1fKB", float64(bytes)/1024)
+
+	// Show how long the input has been silent, or that it is too slow
+	stallStr := stallText(s)
+
+	// Show which side is holding up the transfer
+	waitStr := waitText(s)
+
+	// Show how full the buffer between input and output is
+	bufferStr := bufferText(s)
+
+	// Build status text
+	var statusText string
+	if s.TotalSize > 0 {
+		statusText = fmt.Sprintf("%s of %s (%s) @ %s%s%s%s%s", readStr, totalStr, completionStr, rateStr, etaStr, stallStr, waitStr, bufferStr)
+	} else {
+		statusText = fmt.Sprintf("%s @ %s%s%s%s   ", readStr, rateStr, stallStr, waitStr, bufferStr)
 	}
-	if bytes < 1024*1024*1024 {
-		return fmt.Sprintf("%.2fMB", float64(bytes)/(1024*1024))
+
+	return statusText
+}
+
+// percentComplete calculates the completion percentage, or 0 if the size is unknown
+func (p *Progress) percentComplete(bytesRead int64) float64 {
+	if p.totalSize <= 0 {
+		return 0
 	}
-	return fmt.Sprintf("%.2fGB", float64(bytes)/(1024*1024*1024))
+	return min(float64(bytesRead)/float64(p.totalSize)*100.0, 100.0)
+}
+
+// transferRate calculates the average transfer rate in bytes per second,
+// counting only the bytes moved since a resumed transfer restarted
+func (p *Progress) transferRate(bytesRead int64, elapsed time.Duration) float64 {
+	return float64(bytesRead-p.resumeOffset) / max(elapsed.Seconds(), 0.001)
+}
+
+// eta estimates the seconds remaining, or -1 if it cannot be estimated
+func (p *Progress) eta(bytesRead int64, bytesPerSec float64) float64 {
+	if p.totalSize <= 0 || bytesPerSec <= 0 {
+		return -1
+	}
+	bytesRemaining := p.totalSize - bytesRead
+	if bytesRemaining <= 0 {
+		return 0
+	}
+	return float64(bytesRemaining) / bytesPerSec
+}
+
+// abort stops Process with the given error; only the first call has any effect
+func (p *Progress) abort(err error) {
+	p.abortOnce.Do(func() {
+		p.abortErr = err
+		close(p.aborted)
+	})
+}
+
+// warn prints a warning on stderr without mangling the progress bar
+func (p *Progress) warn(msg string) {
+	if p.logMode {
+		fmt.Fprintln(p.displayWriter(), formatLogLine(p.now(), "Warning: "+msg))
+	} else {
+		fmt.Fprintf(p.displayWriter(), "\nWarning: %s\n", msg)
+	}
+}
+
+// formatSize formats bytes to human-readable string
+func formatSize(bytes int64) string {
+	return sizeUnits{}.format(bytes)
 }
 
 // formatDuration formats seconds into a human-readable duration string
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"os"
 	"strings"
@@ -97,7 +98,6 @@ func TestProcessWithDifferentSizes(t *testing.T) {
 
 			// Create a Progress instance
 			p := &Progress{
-				bytesRead:   0,
 				totalSize:   int64(size),
 				startTime:   time.Now(),
 				lastUpdate:  time.Now().Add(-1 * time.Hour),
@@ -113,7 +113,7 @@ func TestProcessWithDifferentSizes(t *testing.T) {
 			}()
 
 			// Process the data
-			err := p.Process()
+			err := p.Process(context.Background())
 			if err != nil {
 				t.Errorf("Process() returned error: %v", err)
 			}
@@ -130,8 +130,8 @@ func TestProcessWithDifferentSizes(t *testing.T) {
 			}
 
 			// Verify bytes read
-			if p.bytesRead != int64(size) {
-				t.Errorf("Expected bytesRead to be %d, got %d", size, p.bytesRead)
+			if p.bytesRead.Load() != int64(size) {
+				t.Errorf("Expected bytesRead to be %d, got %d", size, p.bytesRead.Load())
 			}
 		})
 	}
@@ -155,10 +155,10 @@ func TestProcessWithWriteError(t *testing.T) {
 func TestBuildProgressBarIndeterminate(t *testing.T) {
 	// Test with indeterminate progress (totalSize = 0)
 	p := &Progress{
-		bytesRead: 1024,
 		totalSize: 0, // Indeterminate
 		barSize:   10,
 	}
+	p.bytesRead.Store(1024)
 
 	// Test at different elapsed times to verify the moving block
 	elapsedTimes := []time.Duration{
@@ -242,11 +242,11 @@ func TestBuildProgressBarEdgeCases(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			p := &Progress{
-				bytesRead: tc.bytesRead,
 				totalSize: tc.totalSize,
 				barSize:   10,
 				startTime: time.Now().Add(-tc.elapsed),
 			}
+			p.bytesRead.Store(tc.bytesRead)
 
 			bar := p.buildProgressBar(tc.elapsed)
 
@@ -272,7 +272,7 @@ func TestFormatSizeEdgeCases(t *testing.T) {
 	// Test with very large values
 	veryLarge := int64(9223372036854775807) // max int64
 	result := formatSize(veryLarge)
-	if !strings.Contains(result, "GB") {
+	if result != "8.00EB" {
 		t.Errorf("Expected formatSize to handle very large values, got %s", result)
 	}
 
@@ -2,15 +2,19 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
 
-// mockTime is a struct that implements a mock time for testing
+// mockTime is a struct that implements a mock time for testing.
+// It satisfies Clock and is safe to advance while Process is running.
 type mockTime struct {
+	mu          sync.Mutex
 	currentTime time.Time
 }
 
@@ -21,10 +25,14 @@ func newMockTime(startTime time.Time) *mockTime {
 }
 
 func (mt *mockTime) Now() time.Time {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
 	return mt.currentTime
 }
 
 func (mt *mockTime) Advance(d time.Duration) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
 	mt.currentTime = mt.currentTime.Add(d)
 }
 
@@ -42,7 +50,6 @@ func TestUpdateDisplayOutput(t *testing.T) {
 
 	// Create a Progress instance
 	p := &Progress{
-		bytesRead:   500,
 		totalSize:   1000,
 		startTime:   time.Now().Add(-10 * time.Second),
 		lastUpdate:  time.Now().Add(-1 * time.Second),
@@ -50,6 +57,7 @@ func TestUpdateDisplayOutput(t *testing.T) {
 		quiet:       false,
 		barSize:     20,
 	}
+	p.bytesRead.Store(500)
 
 	// Call updateDisplay
 	p.updateDisplay()
@@ -86,7 +94,6 @@ func TestBuildProgressBarWithMockTime(t *testing.T) {
 
 	// Create a Progress instance with the fixed start time
 	p := &Progress{
-		bytesRead:   0,
 		totalSize:   1000,
 		startTime:   startTime,
 		lastUpdate:  startTime,
@@ -102,7 +109,7 @@ func TestBuildProgressBarWithMockTime(t *testing.T) {
 	}
 
 	// Update progress to 50% at t+5s
-	p.bytesRead = 500
+	p.bytesRead.Store(500)
 	bar2 := p.buildProgressBar(5 * time.Second)
 
 	// Check percentage
@@ -116,7 +123,7 @@ func TestBuildProgressBarWithMockTime(t *testing.T) {
 	}
 
 	// Update progress to 100% at t+10s
-	p.bytesRead = 1000
+	p.bytesRead.Store(1000)
 	bar3 := p.buildProgressBar(10 * time.Second)
 
 	// Check percentage
@@ -137,7 +144,6 @@ func TestIndeterminateProgressWithMockTime(t *testing.T) {
 
 	// Create a Progress instance with indeterminate size
 	p := &Progress{
-		bytesRead:   1024,
 		totalSize:   0, // Indeterminate
 		startTime:   startTime,
 		lastUpdate:  startTime,
@@ -145,6 +151,7 @@ func TestIndeterminateProgressWithMockTime(t *testing.T) {
 		quiet:       false,
 		barSize:     10,
 	}
+	p.bytesRead.Store(1024)
 
 	// Test at different time points to verify the moving indicator
 	timePoints := []time.Duration{
@@ -222,3 +229,85 @@ func TestFormatDurationTable(t *testing.T) {
 		}
 	}
 }
+
+// waitForBytes waits until the transfer has moved n bytes
+func waitForBytes(t *testing.T, p *Progress, n int64) {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		if p.Snapshot().BytesRead >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %d bytes, got %d", n, p.Snapshot().BytesRead)
+}
+
+// frameWriter hands each display write to the test as one frame
+type frameWriter chan string
+
+func (w frameWriter) Write(b []byte) (int, error) {
+	w <- string(b)
+	return len(b), nil
+}
+
+// TestProcessWithMockTime drives a whole Process run with a mock clock and manual ticks
+func TestProcessWithMockTime(t *testing.T) {
+	startTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := newMockTime(startTime)
+	ticks := make(chan time.Time)
+	inputR, inputW := io.Pipe()
+	frames := make(frameWriter, 10)
+	var output bytes.Buffer
+
+	p := &Progress{
+		totalSize:   1000,
+		startTime:   startTime,
+		lastUpdate:  startTime,
+		refreshRate: time.Second,
+		debug:       true, // One line per frame
+		barSize:     10,
+		clock:       clock,
+		ticks:       ticks,
+		input:       inputR,
+		output:      &output,
+		display:     frames,
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		result <- p.Process(context.Background())
+	}()
+
+	// Move half the data, then let five seconds pass and draw a frame
+	inputW.Write(bytes.Repeat([]byte("a"), 500))
+	waitForBytes(t, p, 500)
+	clock.Advance(5 * time.Second)
+	ticks <- clock.Now()
+	if frame := <-frames; !strings.HasPrefix(frame, "[=====>    ] 500B of 1000B (50.0%) @ 100B/s ETA: 5s") {
+		t.Errorf("Unexpected first frame '%s'", frame)
+	}
+
+	inputW.Write(bytes.Repeat([]byte("b"), 500))
+	waitForBytes(t, p, 1000)
+	clock.Advance(5 * time.Second)
+	ticks <- clock.Now()
+	if frame := <-frames; !strings.HasPrefix(frame, "[==========] 1000B of 1000B (100.0%) @ 100B/s Done!") {
+		t.Errorf("Unexpected second frame '%s'", frame)
+	}
+
+	inputW.Close()
+	if err := <-result; err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+
+	// The final frame is drawn at the same virtual time, followed by a newline
+	if frame := <-frames; !strings.HasPrefix(frame, "[==========] 1000B of 1000B (100.0%) @ 100B/s Done!") {
+		t.Errorf("Unexpected final frame '%s'", frame)
+	}
+	if output.Len() != 1000 {
+		t.Errorf("Expected 1000 bytes of output, got %d", output.Len())
+	}
+	if s := p.summary(); s.Duration != 10 || s.AvgRate != 100 {
+		t.Errorf("Expected a 10s summary at 100B/s, got %+v", s)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -35,8 +36,8 @@ func TestNewProgress(t *testing.T) {
 	if p.barSize != 40 {
 		t.Errorf("Expected barSize to be 40, got %d", p.barSize)
 	}
-	if p.bytesRead != 0 {
-		t.Errorf("Expected bytesRead to be 0, got %d", p.bytesRead)
+	if p.bytesRead.Load() != 0 {
+		t.Errorf("Expected bytesRead to be 0, got %d", p.bytesRead.Load())
 	}
 }
 
@@ -117,11 +118,11 @@ func TestMax(t *testing.T) {
 func TestBuildProgressBar(t *testing.T) {
 	// Test with known size
 	p1 := &Progress{
-		bytesRead: 50,
 		totalSize: 100,
 		barSize:   10,
 		startTime: time.Now().Add(-1 * time.Second),
 	}
+	p1.bytesRead.Store(50)
 
 	bar1 := p1.buildProgressBar(1 * time.Second)
 	if !strings.Contains(bar1, "50.0%") {
@@ -133,11 +134,11 @@ func TestBuildProgressBar(t *testing.T) {
 
 	// Test with unknown size
 	p2 := &Progress{
-		bytesRead: 1024,
 		totalSize: 0,
 		barSize:   10,
 		startTime: time.Now().Add(-1 * time.Second),
 	}
+	p2.bytesRead.Store(1024)
 
 	bar2 := p2.buildProgressBar(1 * time.Second)
 	if !strings.Contains(bar2, "1.0KB") {
@@ -152,7 +153,6 @@ func TestBuildProgressBar(t *testing.T) {
 func TestProcess(t *testing.T) {
 	// Create a Progress instance
 	p := &Progress{
-		bytesRead:   0,
 		totalSize:   100,
 		startTime:   time.Now(),
 		lastUpdate:  time.Now().Add(-1 * time.Hour),
@@ -186,7 +186,7 @@ func TestProcess(t *testing.T) {
 	}()
 
 	// Process the data
-	err := p.Process()
+	err := p.Process(context.Background())
 	if err != nil {
 		t.Errorf("Process() returned error: %v", err)
 	}
@@ -203,8 +203,8 @@ func TestProcess(t *testing.T) {
 	}
 
 	// Verify bytes read
-	if p.bytesRead != 100 {
-		t.Errorf("Expected bytesRead to be 100, got %d", p.bytesRead)
+	if p.bytesRead.Load() != 100 {
+		t.Errorf("Expected bytesRead to be 100, got %d", p.bytesRead.Load())
 	}
 }
 
@@ -326,3 +326,47 @@ func TestGetSize(t *testing.T) {
 		t.Errorf("Expected size %d, got %d", expectedSize, outputSize)
 	}
 }
+
+// TestParseSize tests the parseSize function
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		system   string
+		expected int64
+	}{
+		{"0", "", 0},
+		{"100", "", 100},
+		{"1K", "", 1024},
+		{"1.5k", "", 1536},
+		{"5M", "", 5 * 1024 * 1024},
+		{"5MB", "", 5 * 1024 * 1024},
+		{"5MB", "iec", 5 * 1024 * 1024},
+		{"5MB", "si", 5 * 1000 * 1000},
+		{"5MiB", "si", 5 * 1024 * 1024},
+		{"5M", "si", 5 * 1024 * 1024},
+		{"1.5GiB", "", 1536 * 1024 * 1024},
+		{"2TB", "si", 2 * 1000 * 1000 * 1000 * 1000},
+		{"2TB", "", 2 * 1024 * 1024 * 1024 * 1024},
+		{"1kB", "si", 1000},
+		{"1KB", "", 1024},
+		{"100B", "", 100},
+		{"1E", "", 1 << 60},
+		{"2G", "", 2 * 1024 * 1024 * 1024},
+		{"1T", "", 1024 * 1024 * 1024 * 1024},
+	}
+
+	for _, test := range tests {
+		result, err := parseSize(test.input, test.system)
+		if err != nil {
+			t.Errorf("parseSize(%q, %q) returned error: %v", test.input, test.system, err)
+		} else if result != test.expected {
+			t.Errorf("parseSize(%q, %q) = %d, expected %d", test.input, test.system, result, test.expected)
+		}
+	}
+
+	for _, input := range []string{"", "abc", "-5M", "5X", "inf", "8EiB", "B"} {
+		if _, err := parseSize(input, ""); err == nil {
+			t.Errorf("parseSize(%q) expected error", input)
+		}
+	}
+}
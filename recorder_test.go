@@ -0,0 +1,171 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "Rewrite the golden files in testdata")
+
+// recordedFrame is one display write and the virtual time since start it was made at
+type recordedFrame struct {
+	at   time.Duration
+	text string
+}
+
+// frameRecorder is a display writer that keeps every frame with its virtual timestamp
+type frameRecorder struct {
+	clock  Clock
+	start  time.Time
+	frames []recordedFrame
+}
+
+func newFrameRecorder(clock Clock) *frameRecorder {
+	return &frameRecorder{clock: clock, start: clock.Now()}
+}
+
+func (r *frameRecorder) Write(b []byte) (int, error) {
+	// Each frame is a single write; the bare newline after the last one is not a frame
+	text := strings.Trim(string(b), "\r\n")
+	if text != "" {
+		r.frames = append(r.frames, recordedFrame{at: r.clock.Now().Sub(r.start), text: text})
+	}
+	return len(b), nil
+}
+
+// String lists the frames one per line, quoted so trailing spaces are visible in diffs
+func (r *frameRecorder) String() string {
+	var b strings.Builder
+	for _, f := range r.frames {
+		fmt.Fprintf(&b, "%6.2fs %q\n", f.at.Seconds(), f.text)
+	}
+	return b.String()
+}
+
+// assertGolden compares got with testdata/name.golden, rewriting it with -update
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	if *updateGolden {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Error reading golden file (run with -update to create it): %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("Frames differ from %s (run with -update to accept):\n--- want\n%s--- got\n%s", path, want, got)
+	}
+}
+
+// transferStep advances the virtual clock and sets the bytes moved so far
+type transferStep struct {
+	advance time.Duration
+	bytes   int64
+}
+
+// simulateTransfer refreshes once per step as the ticker would, then draws the final frame
+func simulateTransfer(p *Progress, clock *mockTime, steps []transferStep) {
+	p.rates.start(p.startTime, 0, 0)
+	for _, step := range steps {
+		clock.Advance(step.advance)
+		if step.bytes != p.bytesRead.Load() {
+			p.bytesRead.Store(step.bytes)
+			p.lastRead.Store(clock.Now().UnixNano())
+		}
+		p.refresh()
+	}
+	p.finished.Store(true)
+	p.finishDisplay(nil)
+}
+
+// newRecordedProgress creates a Progress drawing into a frame recorder on a mock clock
+func newRecordedProgress(totalSize int64) (*Progress, *mockTime, *frameRecorder) {
+	startTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := newMockTime(startTime)
+	recorder := newFrameRecorder(clock)
+
+	p := &Progress{
+		totalSize:   totalSize,
+		startTime:   startTime,
+		lastUpdate:  startTime,
+		refreshRate: time.Second,
+		barSize:     20,
+		clock:       clock,
+		display:     recorder,
+	}
+	return p, clock, recorder
+}
+
+// TestGoldenKnownSize pins the bar layout across a transfer of known size
+func TestGoldenKnownSize(t *testing.T) {
+	p, clock, recorder := newRecordedProgress(10 * 1024 * 1024)
+
+	simulateTransfer(p, clock, []transferStep{
+		{time.Second, 512},
+		{time.Second, 300 * 1024},
+		{time.Second, 2 * 1024 * 1024},
+		{time.Second, 5 * 1024 * 1024},
+		{time.Second, 9 * 1024 * 1024},
+		{time.Second, 10 * 1024 * 1024},
+	})
+
+	assertGolden(t, "known_size", recorder.String())
+}
+
+// TestGoldenIndeterminate pins the moving block when the size is unknown
+func TestGoldenIndeterminate(t *testing.T) {
+	p, clock, recorder := newRecordedProgress(0)
+
+	var steps []transferStep
+	for i := int64(1); i <= 12; i++ {
+		steps = append(steps, transferStep{250 * time.Millisecond, i * 4096})
+	}
+	simulateTransfer(p, clock, steps)
+
+	assertGolden(t, "indeterminate", recorder.String())
+}
+
+// TestGoldenStall pins the STALLED indicator while the input is silent
+func TestGoldenStall(t *testing.T) {
+	p, clock, recorder := newRecordedProgress(1000)
+	p.stallThreshold = 2 * time.Second
+
+	simulateTransfer(p, clock, []transferStep{
+		{time.Second, 100},
+		{time.Second, 100},
+		{time.Second, 100},
+		{time.Second, 100},
+		{time.Second, 1000},
+	})
+
+	assertGolden(t, "stall", recorder.String())
+}
+
+// TestGoldenLogMode pins the timestamped lines printed when stderr isn't a terminal
+func TestGoldenLogMode(t *testing.T) {
+	p, clock, recorder := newRecordedProgress(1000)
+	p.logMode = true
+	p.logInterval = 3 * time.Second
+	p.logPercent = 50
+
+	var steps []transferStep
+	for i := int64(1); i <= 10; i++ {
+		steps = append(steps, transferStep{time.Second, i * 100})
+	}
+	simulateTransfer(p, clock, steps)
+
+	assertGolden(t, "log_mode", recorder.String())
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// resume prepares to continue an interrupted transfer: it skips the first
+// skip bytes of stdin and, if outputPath is set, appends to that file. With
+// an output file, skip defaults to its length and must match it if given.
+// The bar starts at the bytes skipped.
+func (p *Progress) resume(outputPath string, skip int64) error {
+	offset := skip
+
+	if outputPath != "" {
+		f, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return fmt.Errorf("error opening resume output: %w", err)
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("error opening resume output: %w", err)
+		}
+		offset = info.Size()
+
+		// Skipping any other amount would leave a gap or a repeat in the output
+		if skip > 0 && skip != offset {
+			f.Close()
+			return fmt.Errorf("skip-input is %d bytes but %s already has %d", skip, outputPath, offset)
+		}
+		p.output = f
+	}
+
+	if offset > 0 {
+		if err := skipInput(p.inputReader(), offset); err != nil {
+			return err
+		}
+	}
+
+	// Measure rate and ETA from now on, counting only the new bytes
+	p.bytesRead.Store(offset)
+	p.resumeOffset = offset
+
+	// The limit counts new bytes, so the bar ends that far past the resume point
+	if p.limitTotal {
+		p.totalSize = offset + p.limit
+	}
+	p.startTime = p.now()
+
+	return nil
+}
+
+// skipInput discards the first n bytes of input, seeking when it is a regular file
+func skipInput(input io.Reader, n int64) error {
+	if f, ok := input.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			// Seeking past the end succeeds, so check the file is long enough first
+			if offset, err := f.Seek(0, io.SeekCurrent); err == nil {
+				if left := info.Size() - offset; left < n {
+					if left < 0 {
+						left = 0
+					}
+					return fmt.Errorf("input ended after %d of %d bytes to skip", left, n)
+				}
+				if _, err := f.Seek(n, io.SeekCurrent); err == nil {
+					return nil
+				}
+			}
+		}
+	}
+
+	skipped, err := io.CopyN(io.Discard, input, n)
+	if err == io.EOF {
+		return fmt.Errorf("input ended after %d of %d bytes to skip", skipped, n)
+	}
+	if err != nil {
+		return fmt.Errorf("error skipping input: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSkipInputPipe tests discarding bytes from a pipe
+func TestSkipInputPipe(t *testing.T) {
+	r, w, _ := os.Pipe()
+	defer r.Close()
+	go func() {
+		w.Write([]byte("0123456789"))
+		w.Close()
+	}()
+
+	if err := skipInput(r, 4); err != nil {
+		t.Fatalf("skipInput() returned error: %v", err)
+	}
+	rest, _ := io.ReadAll(r)
+	if string(rest) != "456789" {
+		t.Errorf("Expected remaining input '456789', got '%s'", rest)
+	}
+}
+
+// TestSkipInputFile tests seeking past bytes of a regular file
+func TestSkipInputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input")
+	os.WriteFile(path, []byte("0123456789"), 0644)
+
+	f, _ := os.Open(path)
+	defer f.Close()
+
+	if err := skipInput(f, 7); err != nil {
+		t.Fatalf("skipInput() returned error: %v", err)
+	}
+	rest, _ := io.ReadAll(f)
+	if string(rest) != "789" {
+		t.Errorf("Expected remaining input '789', got '%s'", rest)
+	}
+}
+
+// TestSkipInputShort tests skipping more bytes than the input has
+func TestSkipInputShort(t *testing.T) {
+	r, w, _ := os.Pipe()
+	defer r.Close()
+	go func() {
+		w.Write([]byte("abc"))
+		w.Close()
+	}()
+
+	if err := skipInput(r, 10); err == nil || !strings.Contains(err.Error(), "3 of 10") {
+		t.Errorf("Expected short input error, got %v", err)
+	}
+}
+
+// TestSkipInputFileShort tests that a regular file is checked for length instead of seeking past its end
+func TestSkipInputFileShort(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input")
+	os.WriteFile(path, []byte("0123456789"), 0644)
+
+	f, _ := os.Open(path)
+	defer f.Close()
+	f.Seek(4, io.SeekStart)
+
+	if err := skipInput(f, 10); err == nil || !strings.Contains(err.Error(), "input ended after 6 of 10 bytes") {
+		t.Errorf("Expected short input error, got %v", err)
+	}
+}
+
+// TestResumeOutput tests appending to a partial output with progress at its length
+func TestResumeOutput(t *testing.T) {
+	input := bytes.Repeat([]byte("x"), 1000)
+	path := filepath.Join(t.TempDir(), "output")
+	os.WriteFile(path, input[:800], 0644)
+
+	oldStdin := os.Stdin
+	defer func() {
+		os.Stdin = oldStdin
+	}()
+	r, w, _ := os.Pipe()
+	os.Stdin = r
+	go func() {
+		w.Write(input)
+		w.Close()
+	}()
+
+	p := &Progress{
+		totalSize:   1000,
+		refreshRate: 100 * time.Millisecond,
+		quiet:       true,
+		barSize:     10,
+	}
+
+	if err := p.resume(path, 800); err != nil {
+		t.Fatalf("resume() returned error: %v", err)
+	}
+	if bar := p.buildProgressBar(time.Second); !strings.Contains(bar, "80.0%") {
+		t.Errorf("Expected bar to start at 80.0%%, got '%s'", bar)
+	}
+
+	if err := p.Process(context.Background()); err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+	p.output.(*os.File).Close()
+
+	output, _ := os.ReadFile(path)
+	if !bytes.Equal(output, input) {
+		t.Errorf("Expected resumed output to match input, got %d bytes", len(output))
+	}
+	if p.bytesRead.Load() != 1000 {
+		t.Errorf("Expected bytesRead to be 1000, got %d", p.bytesRead.Load())
+	}
+	if s := p.summary(); s.Bytes != 200 || s.Resumed != 800 {
+		t.Errorf("Expected summary of 200 new bytes resumed at 800, got %+v", s)
+	}
+}
+
+// TestResumeRate tests that the rate only counts bytes moved since resuming
+func TestResumeRate(t *testing.T) {
+	p := &Progress{
+		resumeOffset: 1000,
+		totalSize:    2000,
+		barSize:      10,
+	}
+	p.bytesRead.Store(1500)
+
+	bar := p.buildProgressBar(5 * time.Second)
+	if !strings.Contains(bar, "@ 100B/s ETA: 5s") {
+		t.Errorf("Expected rate and ETA from resumed bytes only, got '%s'", bar)
+	}
+}
+
+// TestResumeOutputDefaultSkip tests that the input skips the output's length when no skip is given
+func TestResumeOutputDefaultSkip(t *testing.T) {
+	input := bytes.Repeat([]byte("0123456789"), 100)
+	path := filepath.Join(t.TempDir(), "output")
+	os.WriteFile(path, input[:400], 0644)
+
+	p := &Progress{
+		totalSize:   1000,
+		refreshRate: 100 * time.Millisecond,
+		quiet:       true,
+		input:       bytes.NewReader(input),
+	}
+
+	if err := p.resume(path, 0); err != nil {
+		t.Fatalf("resume() returned error: %v", err)
+	}
+	if err := p.Process(context.Background()); err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+	p.output.(*os.File).Close()
+
+	output, _ := os.ReadFile(path)
+	if !bytes.Equal(output, input) {
+		t.Errorf("Expected resumed output to match input, got %d bytes", len(output))
+	}
+}
+
+// TestResumeOutputSkipMismatch tests that a skip other than the output's length is rejected
+func TestResumeOutputSkipMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output")
+	os.WriteFile(path, bytes.Repeat([]byte("x"), 400), 0644)
+
+	p := &Progress{input: bytes.NewReader(make([]byte, 1000))}
+	err := p.resume(path, 300)
+	if err == nil || !strings.Contains(err.Error(), "skip-input is 300 bytes but") || !strings.Contains(err.Error(), "already has 400") {
+		t.Errorf("Expected mismatch error, got %v", err)
+	}
+	if p.output != nil {
+		t.Errorf("Expected output not to be replaced after an error")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// interruptedError reports that the transfer was cancelled by a signal
+type interruptedError struct {
+	signal syscall.Signal
+}
+
+func (e *interruptedError) Error() string {
+	return fmt.Sprintf("interrupted by %s", signalName(e.signal))
+}
+
+// interruptSignal returns the signal that interrupted the transfer, if any
+func interruptSignal(err error) (syscall.Signal, bool) {
+	var interrupted *interruptedError
+	if errors.As(err, &interrupted) {
+		return interrupted.signal, true
+	}
+	return 0, false
+}
+
+// signalName returns the conventional name of the signals we handle
+func signalName(sig syscall.Signal) string {
+	switch sig {
+	case syscall.SIGINT:
+		return "SIGINT"
+	case syscall.SIGTERM:
+		return "SIGTERM"
+	default:
+		return sig.String()
+	}
+}
+
+// notifyStatusSignals prints a status line whenever a status signal arrives,
+// until the returned function is called
+func (p *Progress) notifyStatusSignals() func() {
+	if len(statusSignals) == 0 {
+		return func() {}
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, statusSignals...)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-c:
+				p.printStatusLine()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
+
+// printStatusLine writes the current status as one timestamped line on the
+// display, even with --quiet, without disturbing the data stream
+func (p *Progress) printStatusLine() {
+	now := p.now()
+	line := formatLogLine(now, buildStatusText(p.snapshot(now)))
+
+	// Step off the bar like warn does; the next refresh redraws it
+	if !p.quiet && !p.hideBar && !p.logMode {
+		line = "\n" + line
+	}
+	fmt.Fprintln(p.displayWriter(), line)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestProcessInterrupted tests that cancelling the context flushes output and marks the last frame
+func TestProcessInterrupted(t *testing.T) {
+	inputR, inputW := io.Pipe()
+	defer inputW.Close()
+	var output, display bytes.Buffer
+
+	p := &Progress{
+		totalSize:   1000,
+		startTime:   time.Now(),
+		refreshRate: time.Hour,
+		barSize:     10,
+		debug:       true,
+		input:       inputR,
+		output:      &output,
+		display:     &display,
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- p.Process(ctx)
+	}()
+
+	// The data sits in the output buffer until the interrupt flushes it
+	inputW.Write([]byte("partial"))
+	waitForBytes(t, p, 7)
+	cancel(&interruptedError{syscall.SIGINT})
+
+	err := <-result
+	if sig, ok := interruptSignal(err); !ok || sig != syscall.SIGINT {
+		t.Fatalf("Expected SIGINT interruption, got %v", err)
+	}
+	if output.String() != "partial" {
+		t.Errorf("Expected buffered output to be flushed, got '%s'", output.String())
+	}
+	if !strings.Contains(display.String(), "7B of 1000B (0.7%)") || !strings.Contains(display.String(), " INTERRUPTED") {
+		t.Errorf("Expected final interrupted frame, got '%s'", display.String())
+	}
+
+	var summary bytes.Buffer
+	p.writeSummary(&summary, "human")
+	if !strings.Contains(summary.String(), "Transferred 7B in 0s (interrupted)") {
+		t.Errorf("Expected partial summary, got '%s'", summary.String())
+	}
+}
+
+// TestLogFinalInterrupted tests the closing log line for an interrupted transfer
+func TestLogFinalInterrupted(t *testing.T) {
+	var display bytes.Buffer
+	p := &Progress{startTime: time.Now(), display: &display}
+	p.bytesRead.Store(2048)
+
+	p.logFinal("Interrupted")
+	if !strings.Contains(display.String(), "] Interrupted: 2.0KB in 0s @ ") {
+		t.Errorf("Expected interrupted log line, got '%s'", display.String())
+	}
+}
+
+// TestExitCodeInterrupted tests the shell-style exit codes for signals
+func TestExitCodeInterrupted(t *testing.T) {
+	tests := []struct {
+		sig  syscall.Signal
+		code int
+	}{
+		{syscall.SIGINT, 130},
+		{syscall.SIGTERM, 143},
+	}
+
+	for _, test := range tests {
+		err := fmt.Errorf("wrapped: %w", &interruptedError{test.sig})
+		if code := exitCode(err); code != test.code {
+			t.Errorf("exitCode(%v) = %d, expected %d", test.sig, code, test.code)
+		}
+		if reason := exitReason(err); reason != "interrupted" {
+			t.Errorf("exitReason(%v) = %s, expected interrupted", test.sig, reason)
+		}
+	}
+
+	if _, ok := interruptSignal(errors.New("other")); ok {
+		t.Errorf("Expected other errors not to count as interrupted")
+	}
+	if msg := (&interruptedError{syscall.SIGTERM}).Error(); msg != "interrupted by SIGTERM" {
+		t.Errorf("Unexpected error message '%s'", msg)
+	}
+}
+
+// TestPrintStatusLine tests the one-off status line in quiet and bar modes
+func TestPrintStatusLine(t *testing.T) {
+	startTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := newMockTime(startTime.Add(5 * time.Second))
+	var display bytes.Buffer
+	p := &Progress{
+		totalSize: 1000,
+		startTime: startTime,
+		quiet:     true,
+		clock:     clock,
+		display:   &display,
+	}
+	p.bytesRead.Store(500)
+
+	p.printStatusLine()
+	expected := "[2023-01-01T12:00:05Z] 500B of 1000B (50.0%) @ 100B/s ETA: 5s\n"
+	if display.String() != expected {
+		t.Errorf("Expected quiet status line %q, got %q", expected, display.String())
+	}
+
+	// Below a redrawn bar the line starts on a fresh row
+	display.Reset()
+	p.quiet = false
+	p.printStatusLine()
+	if display.String() != "\n"+expected {
+		t.Errorf("Expected status line after a newline, got %q", display.String())
+	}
+}
@@ -0,0 +1,53 @@
+//go:build unix
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a bytes.Buffer safe to write from the signal goroutine
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// TestStatusSignal tests that SIGUSR1 prints a status line
+func TestStatusSignal(t *testing.T) {
+	var display lockedBuffer
+	p := &Progress{
+		startTime: time.Now(),
+		quiet:     true,
+		display:   &display,
+	}
+	p.bytesRead.Store(1024)
+
+	stop := p.notifyStatusSignals()
+	defer stop()
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	for i := 0; i < 100 && display.String() == ""; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !strings.Contains(display.String(), "] 1.0KB @ ") {
+		t.Errorf("Expected a status line after SIGUSR1, got %q", display.String())
+	}
+}
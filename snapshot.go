@@ -0,0 +1,83 @@
+package main
+
+import (
+	"time"
+)
+
+// Snapshot is an immutable, point-in-time view of a transfer. Renderers such as
+// the bar, log lines, status outputs and metrics each take one snapshot and
+// format it, so they never read the live counters halfway through an update.
+type Snapshot struct {
+	BytesRead int64   `json:"bytes"`
+	TotalSize int64   `json:"total"`     // 0 when indeterminate
+	Percent   float64 `json:"percent"`   // -1 when the total size is unknown
+	Rate      float64 `json:"rate"`      // Average bytes per second
+	RateBits  float64 `json:"rate_bits"` // Average bits per second
+	ETA       float64 `json:"eta"`       // Seconds remaining, -1 when unknown
+	Elapsed   float64 `json:"elapsed"`   // Seconds since start
+	Done      bool    `json:"done"`
+	State     string  `json:"state"`           // running, done or failed
+	Error     string  `json:"error,omitempty"` // Why the transfer failed
+
+	// Details only shown by the bar and log lines
+	elapsed     time.Duration
+	stalled     time.Duration // How long the input has been silent, 0 below the stall threshold
+	slow        bool          // Rate is below --min-rate
+	interrupted bool          // Cancelled by a signal
+	waitKnown   bool          // Whether input and output waits have been measured
+	inputWait   float64       // Share of the last refresh interval blocked on input
+	outputWait  float64       // Share of the last refresh interval blocked on output
+	bufferUsed  int
+	bufferSize  int       // 0 without --buffer-size
+	units       sizeUnits // How sizes and rates are shown
+}
+
+// Snapshot captures the current state of the transfer
+func (p *Progress) Snapshot() Snapshot {
+	return p.snapshot(p.now())
+}
+
+// snapshot captures the state of the transfer as seen at now
+func (p *Progress) snapshot(now time.Time) Snapshot {
+	elapsed := now.Sub(p.startTime)
+	bytesRead := p.bytesRead.Load()
+	bytesPerSec := p.transferRate(bytesRead, elapsed)
+
+	s := Snapshot{
+		BytesRead: bytesRead,
+		Percent:   -1,
+		Rate:      bytesPerSec,
+		RateBits:  bytesPerSec * 8,
+		ETA:       p.eta(bytesRead, bytesPerSec),
+		Elapsed:   elapsed.Seconds(),
+		Done:      p.finished.Load(),
+
+		elapsed: elapsed,
+		stalled: p.stalledFor(now),
+		slow:    p.slow.Load(),
+
+		interrupted: p.interrupted.Load(),
+		units:       p.units,
+	}
+
+	switch failure := p.failure.Load(); {
+	case failure != nil:
+		s.State, s.Error = "failed", *failure
+	case s.Done:
+		s.State = "done"
+	default:
+		s.State = "running"
+	}
+
+	if p.totalSize > 0 {
+		s.TotalSize = p.totalSize
+		s.Percent = p.percentComplete(bytesRead)
+	}
+
+	s.waitKnown, s.inputWait, s.outputWait = p.waits.shares()
+	if ring := p.buffer.Load(); ring != nil {
+		s.bufferUsed, s.bufferSize = ring.fill()
+	}
+
+	return s
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSnapshot tests the values captured for a known-size transfer
+func TestSnapshot(t *testing.T) {
+	startTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := &Progress{
+		totalSize: 1000,
+		startTime: startTime,
+	}
+	p.bytesRead.Store(250)
+
+	s := p.snapshot(startTime.Add(5 * time.Second))
+	if s.BytesRead != 250 || s.Percent != 25 || s.Rate != 50 || s.ETA != 15 || s.Elapsed != 5 {
+		t.Errorf("Unexpected snapshot %+v", s)
+	}
+
+	// Later changes don't affect a snapshot already taken
+	p.bytesRead.Store(500)
+	if s.BytesRead != 250 || !strings.Contains(p.renderBar(s), "250B of 1000B") {
+		t.Errorf("Expected snapshot to keep 250 bytes, got %+v", s)
+	}
+}
+
+// TestSnapshotJSON tests that only the public fields are encoded
+func TestSnapshotJSON(t *testing.T) {
+	p := &Progress{startTime: time.Now(), stallThreshold: time.Nanosecond}
+	p.bytesRead.Store(1)
+
+	data, _ := json.Marshal(p.Snapshot())
+	var fields map[string]any
+	json.Unmarshal(data, &fields)
+
+	for _, key := range []string{"bytes", "total", "percent", "rate", "rate_bits", "eta", "elapsed", "done", "state"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("Expected key '%s' in %s", key, data)
+		}
+	}
+	if len(fields) != 9 {
+		t.Errorf("Expected 9 keys, got %s", data)
+	}
+}
+
+// TestSnapshotConcurrent renders every output while a transfer runs; run with -race
+func TestSnapshotConcurrent(t *testing.T) {
+	testData := bytes.Repeat([]byte("x"), 8*1024*1024)
+	p := &Progress{
+		totalSize:   int64(len(testData)),
+		startTime:   time.Now(),
+		refreshRate: time.Millisecond,
+		quiet:       true,
+		barSize:     20,
+		bufferSize:  1024 * 1024,
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				s := p.Snapshot()
+				p.renderBar(s)
+				encodeStatus(s, "json")
+				p.writeMetrics(io.Discard)
+				p.summary()
+				time.Sleep(time.Millisecond)
+			}
+		}()
+	}
+
+	_, err := runProcess(t, p, func(w *os.File) {
+		w.Write(testData)
+		w.Close()
+	})
+	close(stop)
+	wg.Wait()
+
+	if err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+	if s := p.Snapshot(); s.BytesRead != int64(len(testData)) || !s.Done {
+		t.Errorf("Expected finished snapshot of %d bytes, got %+v", len(testData), s)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errStalled is returned by Process when --stall-timeout is exceeded
+var errStalled = errors.New("transfer stalled")
+
+// sinceLastRead returns how long the input has been silent at the given time
+func (p *Progress) sinceLastRead(now time.Time) time.Duration {
+	last := p.startTime
+	if nanos := p.lastRead.Load(); nanos != 0 {
+		last = time.Unix(0, nanos)
+	}
+	return now.Sub(last)
+}
+
+// stalledFor returns how long the input has been silent, or 0 if data is flowing
+func (p *Progress) stalledFor(now time.Time) time.Duration {
+	if p.stallThreshold <= 0 || p.finished.Load() {
+		return 0
+	}
+
+	silent := p.sinceLastRead(now)
+	if silent < p.stallThreshold {
+		return 0
+	}
+	return silent
+}
+
+// stallText returns the INTERRUPTED, STALLED or SLOW indicator, or an empty string if data is flowing
+func stallText(s Snapshot) string {
+	switch {
+	case s.interrupted:
+		return " INTERRUPTED"
+	case s.stalled > 0:
+		return fmt.Sprintf(" STALLED %s", formatDuration(s.stalled.Seconds()))
+	case s.slow:
+		return " SLOW"
+	}
+	return ""
+}
+
+// checkStall aborts the transfer once the input has been silent for the stall timeout
+func (p *Progress) checkStall(now time.Time) {
+	if p.stallTimeout <= 0 || p.finished.Load() {
+		return
+	}
+
+	if p.sinceLastRead(now) >= p.stallTimeout {
+		p.abort(fmt.Errorf("%w: no data for %s", errStalled, formatDuration(p.stallTimeout.Seconds())))
+	}
+}
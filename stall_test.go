@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStallText tests the STALLED indicator
+func TestStallText(t *testing.T) {
+	startTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := &Progress{
+		totalSize:      1000,
+		startTime:      startTime,
+		barSize:        10,
+		stallThreshold: 10 * time.Second,
+	}
+	p.bytesRead.Store(100)
+	p.lastRead.Store(startTime.Add(5 * time.Second).UnixNano())
+
+	if text := stallText(p.snapshot(startTime.Add(10 * time.Second))); text != "" {
+		t.Errorf("Expected no indicator before the threshold, got '%s'", text)
+	}
+
+	bar := p.buildProgressBar(20 * time.Second)
+	if !strings.Contains(bar, "STALLED 15s") {
+		t.Errorf("Expected bar to contain 'STALLED 15s', got '%s'", bar)
+	}
+
+	// No indicator once the transfer has finished
+	p.finished.Store(true)
+	if text := stallText(p.snapshot(startTime.Add(time.Minute))); text != "" {
+		t.Errorf("Expected no indicator after finishing, got '%s'", text)
+	}
+}
+
+// TestStallTextBeforeFirstRead tests that silence is measured from the start
+func TestStallTextBeforeFirstRead(t *testing.T) {
+	startTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := &Progress{
+		startTime:      startTime,
+		barSize:        10,
+		stallThreshold: time.Second,
+	}
+
+	bar := p.buildProgressBar(3 * time.Second)
+	if !strings.Contains(bar, "0B @ 0B/s STALLED 3s") {
+		t.Errorf("Expected indeterminate bar to show stall, got '%s'", bar)
+	}
+}
+
+// TestProcessStallTimeout tests that Process aborts when the input goes silent
+func TestProcessStallTimeout(t *testing.T) {
+	oldStdin := os.Stdin
+	oldStdout := os.Stdout
+	defer func() {
+		os.Stdin = oldStdin
+		os.Stdout = oldStdout
+	}()
+
+	r, w, _ := os.Pipe()
+	defer w.Close()
+	os.Stdin = r
+
+	outR, outW, _ := os.Pipe()
+	defer outW.Close()
+	os.Stdout = outW
+	go io.Copy(io.Discard, outR)
+
+	p := &Progress{
+		startTime:    time.Now(),
+		refreshRate:  10 * time.Millisecond,
+		quiet:        true,
+		barSize:      10,
+		stallTimeout: 50 * time.Millisecond,
+	}
+
+	// Send a little data, then go silent
+	w.Write([]byte("hello"))
+
+	err := p.Process(context.Background())
+	if !errors.Is(err, errStalled) {
+		t.Fatalf("Expected stall error, got %v", err)
+	}
+	if p.bytesRead.Load() != 5 {
+		t.Errorf("Expected bytesRead to be 5, got %d", p.bytesRead.Load())
+	}
+}
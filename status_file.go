@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// writeStatusFile atomically replaces the status file with the current status
+func (p *Progress) writeStatusFile() error {
+	data, err := encodeStatus(p.Snapshot(), p.statusFormat)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temp file in the same directory so the rename is atomic
+	dir, base := filepath.Split(p.statusFile)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp*")
+	if err != nil {
+		return fmt.Errorf("error creating status file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing status file: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing status file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing status file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), p.statusFile); err != nil {
+		return fmt.Errorf("error replacing status file: %w", err)
+	}
+
+	return nil
+}
+
+// encodeStatus renders a status as JSON or key=value lines
+func encodeStatus(st Snapshot, format string) ([]byte, error) {
+	switch format {
+	case "", "json":
+		data, err := json.Marshal(st)
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case "kv":
+		var b strings.Builder
+		fmt.Fprintf(&b, "bytes=%d\n", st.BytesRead)
+		fmt.Fprintf(&b, "total=%d\n", st.TotalSize)
+		fmt.Fprintf(&b, "percent=%s\n", strconv.FormatFloat(st.Percent, 'f', 1, 64))
+		fmt.Fprintf(&b, "rate=%s\n", strconv.FormatFloat(st.Rate, 'f', 0, 64))
+		fmt.Fprintf(&b, "rate_bits=%s\n", strconv.FormatFloat(st.RateBits, 'f', 0, 64))
+		fmt.Fprintf(&b, "eta=%s\n", strconv.FormatFloat(st.ETA, 'f', 0, 64))
+		fmt.Fprintf(&b, "elapsed=%s\n", strconv.FormatFloat(st.Elapsed, 'f', 1, 64))
+		fmt.Fprintf(&b, "done=%t\n", st.Done)
+		fmt.Fprintf(&b, "state=%s\n", st.State)
+		if st.Error != "" {
+			fmt.Fprintf(&b, "error=%s\n", strings.ReplaceAll(st.Error, "\n", " "))
+		}
+		return []byte(b.String()), nil
+	default:
+		return nil, fmt.Errorf("unknown status format: %s", format)
+	}
+}
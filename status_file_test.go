@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// TestWriteStatusFileJSON tests writing the status file as JSON
+func TestWriteStatusFileJSON(t *testing.T) {
+	dir := t.TempDir()
+	p := &Progress{
+		totalSize:  1024,
+		startTime:  time.Now().Add(-2 * time.Second),
+		statusFile: filepath.Join(dir, "status.json"),
+	}
+	p.bytesRead.Store(512)
+
+	if err := p.writeStatusFile(); err != nil {
+		t.Fatalf("writeStatusFile() returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(p.statusFile)
+	if err != nil {
+		t.Fatalf("Failed to read status file: %v", err)
+	}
+
+	var st Snapshot
+	if err := json.Unmarshal(data, &st); err != nil {
+		t.Fatalf("Failed to decode status file: %v", err)
+	}
+	if st.BytesRead != 512 || st.Percent != 50.0 {
+		t.Errorf("Expected 512 bytes at 50%%, got %d at %f", st.BytesRead, st.Percent)
+	}
+
+	// No temp files should be left behind
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the status file in %s, got %d entries", dir, len(entries))
+	}
+}
+
+// TestWriteStatusFileKeyValue tests writing the status file as key=value lines
+func TestWriteStatusFileKeyValue(t *testing.T) {
+	p := &Progress{
+		totalSize:    0,
+		startTime:    time.Now(),
+		statusFile:   filepath.Join(t.TempDir(), "status"),
+		statusFormat: "kv",
+	}
+	p.bytesRead.Store(100)
+	p.finished.Store(true)
+
+	if err := p.writeStatusFile(); err != nil {
+		t.Fatalf("writeStatusFile() returned error: %v", err)
+	}
+
+	data, _ := os.ReadFile(p.statusFile)
+	output := string(data)
+
+	for _, expected := range []string{"bytes=100\n", "total=0\n", "percent=-1.0\n", "eta=-1\n", "done=true\n", "state=done\n"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected status file to contain '%s', got '%s'", expected, output)
+		}
+	}
+}
+
+// TestWriteStatusFileErrors tests error handling for bad formats and paths
+func TestWriteStatusFileErrors(t *testing.T) {
+	p := &Progress{
+		startTime:    time.Now(),
+		statusFile:   filepath.Join(t.TempDir(), "status"),
+		statusFormat: "xml",
+	}
+	if err := p.writeStatusFile(); err == nil {
+		t.Errorf("Expected error for unknown status format")
+	}
+
+	p.statusFormat = "json"
+	p.statusFile = filepath.Join(t.TempDir(), "missing", "status")
+	if err := p.writeStatusFile(); err == nil {
+		t.Errorf("Expected error for missing directory")
+	}
+}
+
+// TestProcessStatusFileOnFailure tests that a failed transfer leaves a final status saying so
+func TestProcessStatusFileOnFailure(t *testing.T) {
+	p := &Progress{
+		startTime:   time.Now(),
+		refreshRate: time.Hour,
+		quiet:       true,
+		statusFile:  filepath.Join(t.TempDir(), "status.json"),
+		input:       iotest.ErrReader(errors.New("disk gone")),
+		output:      io.Discard,
+	}
+
+	if err := p.Process(context.Background()); err == nil {
+		t.Fatalf("Expected Process to fail")
+	}
+
+	data, err := os.ReadFile(p.statusFile)
+	if err != nil {
+		t.Fatalf("Failed to read status file: %v", err)
+	}
+	var st Snapshot
+	if err := json.Unmarshal(data, &st); err != nil {
+		t.Fatalf("Failed to decode status file: %v", err)
+	}
+	if st.Done || st.State != "failed" || !strings.Contains(st.Error, "disk gone") {
+		t.Errorf("Expected a failed status with the error, got %s", data)
+	}
+}
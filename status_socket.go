@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// statusCommandTimeout is how long a client has to send a command before
+// it is treated as a one-off status query
+const statusCommandTimeout = 500 * time.Millisecond
+
+// statusWriteTimeout is how long a client may leave a snapshot unread
+// before it is dropped, so a stuck client can't hold up Close
+const statusWriteTimeout = time.Second
+
+// statusServer serves progress snapshots as JSON over a unix socket.
+//
+// Clients may send a single command line after connecting:
+//   - "status" (or nothing) returns one snapshot and closes the connection
+//   - "watch" streams a snapshot every refresh until the transfer finishes
+type statusServer struct {
+	progress *Progress
+	listener net.Listener
+	quit     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// serveStatusSocket starts a status server listening on the unix socket path
+func (p *Progress) serveStatusSocket(path string) (*statusServer, error) {
+	// Remove a stale socket left behind by a previous run, but not one another
+	// instance is still serving
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.DialTimeout("unix", path, statusCommandTimeout); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("error starting status socket: %s is in use by another process", path)
+		}
+		os.Remove(path)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("error starting status socket: %w", err)
+	}
+
+	s := &statusServer{
+		progress: p,
+		listener: listener,
+		quit:     make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.acceptLoop()
+
+	return s, nil
+}
+
+// Close stops accepting clients, sends watchers a final snapshot and removes the socket
+func (s *statusServer) Close() error {
+	close(s.quit)
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+// acceptLoop accepts client connections until the listener is closed
+func (s *statusServer) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handle(conn)
+		}()
+	}
+}
+
+// handle serves a single client connection
+func (s *statusServer) handle(conn net.Conn) {
+	conn.SetReadDeadline(time.Now().Add(statusCommandTimeout))
+	line, _ := bufio.NewReader(conn).ReadString('\n')
+	conn.SetReadDeadline(time.Time{})
+
+	w := deadlineWriter{conn}
+	encoder := json.NewEncoder(w)
+
+	switch cmd := strings.TrimSpace(line); cmd {
+	case "", "status":
+		encoder.Encode(s.progress.Snapshot())
+	case "watch":
+		s.watch(encoder)
+	default:
+		fmt.Fprintf(w, "{\"error\":%q}\n", "unknown command: "+cmd)
+	}
+}
+
+// deadlineWriter sets a fresh write deadline before every write to a client
+type deadlineWriter struct {
+	conn net.Conn
+}
+
+func (w deadlineWriter) Write(b []byte) (int, error) {
+	w.conn.SetWriteDeadline(time.Now().Add(statusWriteTimeout))
+	return w.conn.Write(b)
+}
+
+// watch streams snapshots until the transfer finishes or the server closes
+func (s *statusServer) watch(encoder *json.Encoder) {
+	interval := s.progress.refreshRate
+	if interval <= 0 {
+		interval = 100 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		st := s.progress.Snapshot()
+		if err := encoder.Encode(st); err != nil || st.Done {
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.quit:
+			encoder.Encode(s.progress.Snapshot())
+			return
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStatus tests the progress snapshot values
+func TestStatus(t *testing.T) {
+	p := &Progress{
+		totalSize: 1000,
+		startTime: time.Now().Add(-5 * time.Second),
+	}
+	p.bytesRead.Store(250)
+
+	st := p.Snapshot()
+	if st.BytesRead != 250 || st.TotalSize != 1000 {
+		t.Errorf("Expected 250 of 1000 bytes, got %d of %d", st.BytesRead, st.TotalSize)
+	}
+	if st.Percent != 25.0 {
+		t.Errorf("Expected percent to be 25.0, got %f", st.Percent)
+	}
+	if st.ETA <= 0 {
+		t.Errorf("Expected a positive ETA, got %f", st.ETA)
+	}
+	if st.Done {
+		t.Errorf("Expected transfer not to be done")
+	}
+
+	// Indeterminate size reports unknown percent and ETA
+	p.totalSize = 0
+	st = p.Snapshot()
+	if st.Percent != -1 || st.ETA != -1 {
+		t.Errorf("Expected unknown percent and ETA, got %f and %f", st.Percent, st.ETA)
+	}
+}
+
+// TestStatusSocket tests one-off and streaming status queries
+func TestStatusSocket(t *testing.T) {
+	p := &Progress{
+		totalSize:   400,
+		startTime:   time.Now(),
+		refreshRate: 10 * time.Millisecond,
+	}
+	p.bytesRead.Store(100)
+
+	path := filepath.Join(t.TempDir(), "prgz.sock")
+	server, err := p.serveStatusSocket(path)
+	if err != nil {
+		t.Fatalf("serveStatusSocket() returned error: %v", err)
+	}
+
+	// One-off query
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to connect to status socket: %v", err)
+	}
+	conn.Write([]byte("status\n"))
+
+	var st Snapshot
+	if err := json.NewDecoder(conn).Decode(&st); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	conn.Close()
+
+	if st.BytesRead != 100 || st.TotalSize != 400 {
+		t.Errorf("Expected 100 of 400 bytes, got %d of %d", st.BytesRead, st.TotalSize)
+	}
+
+	// Streaming query ends with a final snapshot once the server closes
+	conn, err = net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to connect to status socket: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("watch\n"))
+
+	scanner := bufio.NewScanner(conn)
+	if !scanner.Scan() {
+		t.Fatalf("Expected a status line from watch")
+	}
+
+	p.bytesRead.Store(400)
+	p.finished.Store(true)
+	server.Close()
+
+	var last Snapshot
+	for scanner.Scan() {
+		if err := json.Unmarshal(scanner.Bytes(), &last); err != nil {
+			t.Fatalf("Failed to decode streamed status: %v", err)
+		}
+	}
+	if !last.Done || last.BytesRead != 400 {
+		t.Errorf("Expected final streamed status to be done at 400 bytes, got %+v", last)
+	}
+}
+
+// TestStatusSocketStuckWatcher tests that a watcher that stops reading can't hold up Close
+func TestStatusSocketStuckWatcher(t *testing.T) {
+	p := &Progress{
+		startTime:   time.Now(),
+		refreshRate: time.Microsecond,
+	}
+
+	path := filepath.Join(t.TempDir(), "prgz.sock")
+	server, err := p.serveStatusSocket(path)
+	if err != nil {
+		t.Fatalf("serveStatusSocket() returned error: %v", err)
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to connect to status socket: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("watch\n"))
+
+	// Let the snapshots fill the socket buffers
+	time.Sleep(time.Second)
+
+	closed := make(chan struct{})
+	go func() {
+		server.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(statusWriteTimeout + 2*time.Second):
+		t.Fatalf("Close() blocked on a client that stopped reading")
+	}
+}
+
+// TestStatusSocketInUse tests that a live socket is left alone while a stale one is replaced
+func TestStatusSocketInUse(t *testing.T) {
+	p := &Progress{startTime: time.Now()}
+	path := filepath.Join(t.TempDir(), "prgz.sock")
+
+	server, err := p.serveStatusSocket(path)
+	if err != nil {
+		t.Fatalf("serveStatusSocket() returned error: %v", err)
+	}
+	if _, err := p.serveStatusSocket(path); err == nil || !strings.Contains(err.Error(), "in use by another process") {
+		t.Errorf("Expected an in-use error for a live socket, got %v", err)
+	}
+
+	// A socket whose server has gone away is stale
+	listener, err := net.Listen("unix", path+".stale")
+	if err != nil {
+		t.Fatalf("Failed to create socket: %v", err)
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+	stale, err := p.serveStatusSocket(path + ".stale")
+	if err != nil {
+		t.Fatalf("Expected a stale socket to be replaced, got %v", err)
+	}
+	stale.Close()
+	server.Close()
+}
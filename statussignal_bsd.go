@@ -0,0 +1,11 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// statusSignals request a one-off status line; Ctrl-T sends SIGINFO on BSD terminals
+var statusSignals = []os.Signal{syscall.SIGUSR1, syscall.SIGINFO}
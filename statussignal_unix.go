@@ -0,0 +1,11 @@
+//go:build unix && !(darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// statusSignals request a one-off status line, like dd
+var statusSignals = []os.Signal{syscall.SIGUSR1}
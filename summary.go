@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// rateStats accumulates per-refresh transfer rates for the summary and --min-rate
+type rateStats struct {
+	mu        sync.Mutex
+	lastTime  time.Time
+	lastBytes int64
+	peak      float64
+	min       float64 // Slowest interval in which data moved, -1 until one is seen
+	stallTime time.Duration
+
+	window  time.Duration // How much history to keep for windowRate, 0 for none
+	history []rateSample
+}
+
+// rateSample is the byte count observed at a point in time
+type rateSample struct {
+	time  time.Time
+	bytes int64
+}
+
+// start resets the statistics at the beginning of a transfer
+func (r *rateStats) start(now time.Time, bytesRead int64, window time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastTime = now
+	r.lastBytes = bytesRead
+	r.peak = 0
+	r.min = -1
+	r.stallTime = 0
+	r.window = window
+	r.history = nil
+	if window > 0 {
+		r.history = append(r.history, rateSample{time: now, bytes: bytesRead})
+	}
+}
+
+// record adds the interval since the previous sample
+func (r *rateStats) record(now time.Time, bytesRead int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	interval := now.Sub(r.lastTime)
+	if interval <= 0 {
+		return
+	}
+
+	delta := bytesRead - r.lastBytes
+	r.lastTime = now
+	r.lastBytes = bytesRead
+
+	if r.window > 0 {
+		r.history = append(r.history, rateSample{time: now, bytes: bytesRead})
+		// Keep one sample at or before the window start as the baseline
+		for len(r.history) > 1 && !r.history[1].time.After(now.Add(-r.window)) {
+			r.history = r.history[1:]
+		}
+	}
+
+	if delta == 0 {
+		r.stallTime += interval
+		return
+	}
+
+	rate := float64(delta) / interval.Seconds()
+	if rate > r.peak {
+		r.peak = rate
+	}
+	if r.min < 0 || rate < r.min {
+		r.min = rate
+	}
+}
+
+// windowRate returns the rate over the last window, or false until a full window has passed
+func (r *rateStats) windowRate() (float64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.history) < 2 {
+		return 0, false
+	}
+
+	first, last := r.history[0], r.history[len(r.history)-1]
+	span := last.time.Sub(first.time)
+	if span < r.window {
+		return 0, false
+	}
+	return float64(last.bytes-first.bytes) / span.Seconds(), true
+}
+
+// transferSummary is the end-of-transfer report
+type transferSummary struct {
+	Bytes      int64   `json:"bytes"` // Bytes moved by this run
+	Resumed    int64   `json:"resumed_from,omitempty"`
+	Duration   float64 `json:"duration"`      // Wall time in seconds
+	AvgRate    float64 `json:"avg_rate"`      // Bytes per second
+	AvgBits    float64 `json:"avg_rate_bits"` // Bits per second
+	PeakRate   float64 `json:"peak_rate"`     // Fastest refresh interval
+	MinRate    float64 `json:"min_rate"`      // Slowest refresh interval that moved data
+	StallTime  float64 `json:"stall_time"`    // Seconds spent in intervals without data
+	InputWait  float64 `json:"input_wait"`    // Seconds blocked reading stdin
+	OutputWait float64 `json:"output_wait"`   // Seconds blocked writing stdout
+	Done       bool    `json:"done"`
+	Stopped    string  `json:"stopped,omitempty"` // limit, max-duration or interrupted when stopped before EOF
+}
+
+// summary builds the transfer summary from the current state
+func (p *Progress) summary() transferSummary {
+	now := p.now()
+	s := p.snapshot(now)
+	inputWait, outputWait := p.waits.totals(now)
+
+	p.stopMu.Lock()
+	stopReason := p.stopReason
+	p.stopMu.Unlock()
+
+	p.rates.mu.Lock()
+	defer p.rates.mu.Unlock()
+
+	return transferSummary{
+		Bytes:      s.BytesRead - p.resumeOffset,
+		Resumed:    p.resumeOffset,
+		Duration:   s.Elapsed,
+		AvgRate:    s.Rate,
+		AvgBits:    s.RateBits,
+		PeakRate:   p.rates.peak,
+		MinRate:    max(p.rates.min, 0),
+		StallTime:  p.rates.stallTime.Seconds(),
+		InputWait:  inputWait.Seconds(),
+		OutputWait: outputWait.Seconds(),
+		Done:       s.Done,
+		Stopped:    stopReason,
+	}
+}
+
+// writeSummary writes the transfer summary in human or JSON form
+func (p *Progress) writeSummary(w io.Writer, format string) error {
+	s := p.summary()
+
+	if format == "json" {
+		return json.NewEncoder(w).Encode(s)
+	}
+
+	result := "complete"
+	switch {
+	case s.Stopped == "interrupted":
+		result = "interrupted"
+	case !s.Done:
+		result = "incomplete"
+	case s.Stopped == "limit":
+		result = "stopped at limit"
+	case s.Stopped == "max-duration":
+		result = "stopped at max duration"
+	}
+	if s.Resumed > 0 {
+		result += ", resumed at " + p.units.format(s.Resumed)
+	}
+
+	_, err := fmt.Fprintf(w, "Transferred %s in %s (%s)\nRate: avg %s, peak %s, min %s\nStalled: %s\n",
+		p.units.format(s.Bytes),
+		formatDuration(s.Duration),
+		result,
+		p.units.formatRate(s.AvgRate),
+		p.units.formatRate(s.PeakRate),
+		p.units.formatRate(s.MinRate),
+		formatDuration(s.StallTime))
+	if err != nil || s.InputWait == 0 && s.OutputWait == 0 {
+		return err
+	}
+
+	// Zero-copy copies between regular files can't tell the two sides apart, so only show measured waits
+	_, err = fmt.Fprintf(w, "Waiting: input %s, output %s\n",
+		formatWait(s.InputWait), formatWait(s.OutputWait))
+	return err
+}
+
+// formatWait formats a wait in seconds, keeping sub-second precision for short waits
+func formatWait(seconds float64) string {
+	if seconds < 10 {
+		return fmt.Sprintf("%.2fs", seconds)
+	}
+	return formatDuration(seconds)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRateStats tests peak, min and stall accounting
+func TestRateStats(t *testing.T) {
+	startTime := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var r rateStats
+	r.start(startTime, 0, 0)
+	r.record(startTime.Add(1*time.Second), 100) // 100B/s
+	r.record(startTime.Add(2*time.Second), 400) // 300B/s
+	r.record(startTime.Add(4*time.Second), 400) // stalled for 2s
+	r.record(startTime.Add(5*time.Second), 450) // 50B/s
+	r.record(startTime.Add(5*time.Second), 500) // zero interval is ignored
+
+	if r.peak != 300 {
+		t.Errorf("Expected peak rate 300, got %f", r.peak)
+	}
+	if r.min != 50 {
+		t.Errorf("Expected min rate 50, got %f", r.min)
+	}
+	if r.stallTime != 2*time.Second {
+		t.Errorf("Expected stall time 2s, got %v", r.stallTime)
+	}
+}
+
+// TestWriteSummaryHuman tests the human-readable summary
+func TestWriteSummaryHuman(t *testing.T) {
+	p := &Progress{
+		startTime: time.Now().Add(-2 * time.Second),
+	}
+	p.bytesRead.Store(2048)
+	p.rates.start(p.startTime, 0, 0)
+	p.rates.record(p.startTime.Add(1*time.Second), 1024)
+	p.rates.record(p.startTime.Add(2*time.Second), 2048)
+	p.finished.Store(true)
+
+	var buf bytes.Buffer
+	if err := p.writeSummary(&buf, "human"); err != nil {
+		t.Fatalf("writeSummary() returned error: %v", err)
+	}
+	output := buf.String()
+
+	expectedElements := []string{
+		"Transferred 2.0KB in 2s (complete)",
+		"peak 1.0KB/s, min 1.0KB/s",
+		"Stalled: 0s",
+	}
+	for _, expected := range expectedElements {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected summary to contain '%s', got '%s'", expected, output)
+		}
+	}
+}
+
+// TestWriteSummaryJSON tests the JSON summary
+func TestWriteSummaryJSON(t *testing.T) {
+	p := &Progress{
+		startTime: time.Now().Add(-1 * time.Second),
+	}
+	p.bytesRead.Store(500)
+	p.rates.start(p.startTime, 0, 0)
+
+	var buf bytes.Buffer
+	if err := p.writeSummary(&buf, "json"); err != nil {
+		t.Fatalf("writeSummary() returned error: %v", err)
+	}
+
+	var s transferSummary
+	if err := json.Unmarshal(buf.Bytes(), &s); err != nil {
+		t.Fatalf("Failed to decode summary: %v", err)
+	}
+	if s.Bytes != 500 || s.Done {
+		t.Errorf("Expected 500 bytes and incomplete transfer, got %+v", s)
+	}
+	if s.MinRate != 0 {
+		t.Errorf("Expected min rate 0 without samples, got %f", s.MinRate)
+	}
+}
+
+// TestConfigValidateSummary tests validation of the summary format
+func TestConfigValidateSummary(t *testing.T) {
+	if err := (config{summary: "json"}).validate(); err != nil {
+		t.Errorf("Expected json summary to be valid, got %v", err)
+	}
+	if err := (config{summary: "xml"}).validate(); err == nil {
+		t.Errorf("Expected error for unknown summary format")
+	}
+}
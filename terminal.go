@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OSC 9;4 progress states understood by Windows Terminal, WezTerm, ConEmu and Ghostty
+const (
+	oscProgressClear         = 0
+	oscProgressNormal        = 1
+	oscProgressIndeterminate = 3
+)
+
+// startTerminal saves the window title so it can be restored afterwards
+func (p *Progress) startTerminal() {
+	if p.termTitle {
+		fmt.Fprint(p.displayWriter(), "\x1b[22;0t") // Push title onto the xterm title stack
+	}
+}
+
+// updateTerminal emits the taskbar progress and window title sequences
+func (p *Progress) updateTerminal(s Snapshot) {
+	if !p.termProgress && !p.termTitle {
+		return
+	}
+
+	var seq strings.Builder
+	if p.termProgress {
+		if s.TotalSize > 0 {
+			seq.WriteString(oscProgress(oscProgressNormal, int(s.Percent)))
+		} else {
+			seq.WriteString(oscProgress(oscProgressIndeterminate, 0))
+		}
+	}
+	if p.termTitle {
+		seq.WriteString(oscTitle(buildTitle(s)))
+	}
+
+	fmt.Fprint(p.displayWriter(), seq.String())
+}
+
+// resetTerminal clears the taskbar progress and restores the window title
+func (p *Progress) resetTerminal() {
+	if p.termProgress {
+		fmt.Fprint(p.displayWriter(), oscProgress(oscProgressClear, 0))
+	}
+	if p.termTitle {
+		fmt.Fprint(p.displayWriter(), "\x1b[23;0t") // Pop title from the xterm title stack
+	}
+}
+
+// buildTitle creates a short window title describing the transfer
+func buildTitle(s Snapshot) string {
+	rateStr := s.units.formatRate(s.Rate)
+	if s.TotalSize > 0 {
+		return fmt.Sprintf("prgz %.0f%% %s @ %s", s.Percent, s.units.format(s.BytesRead), rateStr)
+	}
+	return fmt.Sprintf("prgz %s @ %s", s.units.format(s.BytesRead), rateStr)
+}
+
+// oscProgress builds an OSC 9;4 taskbar progress sequence
+func oscProgress(state, percent int) string {
+	return fmt.Sprintf("\x1b]9;4;%d;%d\x07", state, percent)
+}
+
+// oscTitle builds an OSC 0 window title sequence
+func oscTitle(title string) string {
+	return "\x1b]0;" + title + "\x07"
+}
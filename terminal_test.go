@@ -0,0 +1,83 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestOscSequences tests the raw escape sequences
+func TestOscSequences(t *testing.T) {
+	if seq := oscProgress(oscProgressNormal, 42); seq != "\x1b]9;4;1;42\x07" {
+		t.Errorf("Unexpected progress sequence %q", seq)
+	}
+	if seq := oscProgress(oscProgressClear, 0); seq != "\x1b]9;4;0;0\x07" {
+		t.Errorf("Unexpected clear sequence %q", seq)
+	}
+	if seq := oscTitle("prgz 50%"); seq != "\x1b]0;prgz 50%\x07" {
+		t.Errorf("Unexpected title sequence %q", seq)
+	}
+}
+
+// TestUpdateTerminal tests terminal output alongside and instead of the bar
+func TestUpdateTerminal(t *testing.T) {
+	p := &Progress{
+		totalSize:    1000,
+		startTime:    time.Now().Add(-1 * time.Second),
+		barSize:      10,
+		termProgress: true,
+		termTitle:    true,
+	}
+	p.bytesRead.Store(500)
+
+	output := captureStderr(t, p.updateDisplay)
+	expectedElements := []string{
+		"\x1b]9;4;1;50\x07",
+		"\x1b]0;prgz 50% 500B @ ",
+		"\r[=====>    ]",
+	}
+	for _, expected := range expectedElements {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected output to contain %q, got %q", expected, output)
+		}
+	}
+
+	// Terminal sequences only, without the drawn bar
+	p.hideBar = true
+	output = captureStderr(t, p.updateDisplay)
+	if strings.Contains(output, "\r") {
+		t.Errorf("Expected no bar with hidden display, got %q", output)
+	}
+	if !strings.Contains(output, "\x1b]9;4;1;50\x07") {
+		t.Errorf("Expected progress sequence with hidden display, got %q", output)
+	}
+}
+
+// TestUpdateTerminalIndeterminate tests the indeterminate taskbar state
+func TestUpdateTerminalIndeterminate(t *testing.T) {
+	p := &Progress{
+		startTime:    time.Now().Add(-1 * time.Second),
+		termProgress: true,
+		termTitle:    true,
+		hideBar:      true,
+	}
+	p.bytesRead.Store(2048)
+
+	output := captureStderr(t, p.updateDisplay)
+	if !strings.Contains(output, "\x1b]9;4;3;0\x07") {
+		t.Errorf("Expected indeterminate progress sequence, got %q", output)
+	}
+	if !strings.Contains(output, "\x1b]0;prgz 2.0KB @ ") {
+		t.Errorf("Expected title without percentage, got %q", output)
+	}
+}
+
+// TestResetTerminal tests clearing progress and restoring the title
+func TestResetTerminal(t *testing.T) {
+	p := &Progress{termProgress: true, termTitle: true}
+
+	output := captureStderr(t, p.resetTerminal)
+	if output != "\x1b]9;4;0;0\x07\x1b[23;0t" {
+		t.Errorf("Unexpected reset output %q", output)
+	}
+}
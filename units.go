@@ -0,0 +1,208 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Unit labels for each power of the base, from bytes up to exabytes
+var (
+	classicLabels = []string{"B", "KB", "MB", "GB", "TB", "PB", "EB"}
+	iecLabels     = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	siLabels      = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+	bitLabels     = []string{"bit/s", "Kbit/s", "Mbit/s", "Gbit/s", "Tbit/s", "Pbit/s", "Ebit/s"}
+)
+
+// sizeUnits controls how byte counts and rates are shown. The zero value
+// keeps the classic output: powers of 1024 labelled KB, MB and GB.
+type sizeUnits struct {
+	system    string // iec, si, bytes, or empty for the classic labels
+	precision int    // Decimal places when fixed is set
+	fixed     bool   // Otherwise 1 decimal for kilobytes and 2 above
+	rateBits  bool   // Show rates in bits per second with powers of 1000
+}
+
+// newSizeUnits creates the units for --units and --precision, where a
+// negative precision picks the decimals for each unit
+func newSizeUnits(system string, precision int) sizeUnits {
+	return sizeUnits{system: system, precision: precision, fixed: precision >= 0}
+}
+
+// validUnits reports whether system is a known --units value
+func validUnits(system string) bool {
+	switch system {
+	case "", "iec", "si", "bytes":
+		return true
+	}
+	return false
+}
+
+// format formats bytes as a human-readable string in these units
+func (u sizeUnits) format(bytes int64) string {
+	if bytes < 0 {
+		return "?"
+	}
+
+	switch u.system {
+	case "bytes":
+		return fmt.Sprintf("%dB", bytes)
+	case "iec":
+		return u.scale(float64(bytes), 1024, iecLabels)
+	case "si":
+		return u.scale(float64(bytes), 1000, siLabels)
+	}
+	return u.scale(float64(bytes), 1024, classicLabels)
+}
+
+// formatRate formats a rate given in bytes per second, such as 1.50MB/s or
+// 12.0Mbit/s when rates are shown in bits
+func (u sizeUnits) formatRate(bytesPerSec float64) string {
+	if !u.rateBits {
+		return u.format(int64(bytesPerSec)) + "/s"
+	}
+	if bytesPerSec < 0 {
+		return "?"
+	}
+	return u.scale(bytesPerSec*8, 1000, bitLabels)
+}
+
+// scale divides value by base until it is below base, then labels it with
+// the matching unit. Values below the first step are shown without decimals.
+func (u sizeUnits) scale(value, base float64, labels []string) string {
+	unit := 0
+	for value >= base && unit < len(labels)-1 {
+		value /= base
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%.0f%s", math.Floor(value), labels[0])
+	}
+
+	precision := u.precision
+	if !u.fixed {
+		precision = 2
+		if unit == 1 {
+			precision = 1
+		}
+	}
+	return fmt.Sprintf("%.*f%s", precision, value, labels[unit])
+}
+
+// sizeUnitNames lists the suffixes parseSize accepts, for error messages
+const sizeUnitNames = "K, M, G, T, P, E, KiB, MiB, GiB, TiB, PiB, EiB, kB, MB, GB, TB, PB or EB"
+
+// sizeMultiplier returns the bytes in one unit: bare K, M, G... and the IEC
+// forms KiB, MiB... are powers of 1024, while kB, MB... follow the display
+// units, so they are powers of 1000 only for --units=si
+func sizeMultiplier(unit, system string) (float64, bool) {
+	unit = strings.ToUpper(unit)
+	if unit == "" || unit == "B" {
+		return 1, true
+	}
+
+	power := strings.IndexByte("KMGTPE", unit[0]) + 1
+	if power == 0 {
+		return 0, false
+	}
+	switch unit[1:] {
+	case "", "I", "IB":
+		return math.Pow(1024, float64(power)), true
+	case "B":
+		if system == "si" {
+			return math.Pow(1000, float64(power)), true
+		}
+		return math.Pow(1024, float64(power)), true
+	}
+	return 0, false
+}
+
+// parseSize parses a byte count such as 100, 1.5GiB or 2TB in the given
+// --units system. Bare K, M, G, T, P and E and the IEC forms KiB, MiB... are
+// powers of 1024; kB, MB, GB... mean what the display shows, so powers of
+// 1000 with si units and of 1024 otherwise.
+func parseSize(s, system string) (int64, error) {
+	str := strings.TrimSpace(s)
+	if str == "" {
+		return 0, fmt.Errorf("size is empty")
+	}
+
+	// The unit is the trailing run of letters, the number is everything before it
+	split := len(str)
+	for split > 0 && ('a' <= str[split-1] && str[split-1] <= 'z' || 'A' <= str[split-1] && str[split-1] <= 'Z') {
+		split--
+	}
+	number, unit := str[:split], str[split:]
+
+	multiplier, ok := sizeMultiplier(unit, system)
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q, use %s", s, unit, sizeUnitNames)
+	}
+	if number == "" {
+		return 0, fmt.Errorf("invalid size %q: missing number", s)
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, fmt.Errorf("invalid size %q: %q is not a number", s, number)
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("invalid size %q: must not be negative", s)
+	}
+
+	bytes := value * multiplier
+	if bytes >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q: must be below 8EiB", s)
+	}
+	return int64(bytes), nil
+}
+
+// sizeValue is a flag.Value for byte counts written with an optional unit.
+// It keeps the text so kB, MB... can be read again once --units is known.
+type sizeValue struct {
+	bytes *int64
+	text  string
+}
+
+// sizeVar defines a size flag with the given name and usage that stores into p
+func sizeVar(p *int64, name, usage string) {
+	flag.Var(&sizeValue{bytes: p}, name, usage)
+}
+
+func (v *sizeValue) String() string {
+	if v.bytes == nil {
+		return "0"
+	}
+	return strconv.FormatInt(*v.bytes, 10)
+}
+
+func (v *sizeValue) Set(s string) error {
+	bytes, err := parseSize(s, "")
+	if err != nil {
+		return err
+	}
+	*v.bytes = bytes
+	v.text = s
+	return nil
+}
+
+// applySizeUnits reads every size flag that was set again in the --units
+// system, as flags are parsed before the units are known
+func applySizeUnits(fs *flag.FlagSet, system string) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		v, ok := f.Value.(*sizeValue)
+		if !ok || v.text == "" || err != nil {
+			return
+		}
+		var bytes int64
+		if bytes, err = parseSize(v.text, system); err != nil {
+			err = fmt.Errorf("invalid value %q for %s: %w", v.text, f.Name, err)
+			return
+		}
+		*v.bytes = bytes
+	})
+	return err
+}
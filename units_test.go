@@ -0,0 +1,178 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSizeUnitsFormat tests each unit system and fixed precision
+func TestSizeUnitsFormat(t *testing.T) {
+	tests := []struct {
+		units    sizeUnits
+		bytes    int64
+		expected string
+	}{
+		{sizeUnits{}, 1536, "1.5KB"},
+		{sizeUnits{}, 3 * 1024 * 1024 * 1024 * 1024, "3.00TB"},
+		{sizeUnits{}, 5 << 50, "5.00PB"},
+		{newSizeUnits("iec", -1), 1023, "1023B"},
+		{newSizeUnits("iec", -1), 1536, "1.5KiB"},
+		{newSizeUnits("iec", -1), 1024 * 1024, "1.00MiB"},
+		{newSizeUnits("iec", -1), 2 << 40, "2.00TiB"},
+		{newSizeUnits("si", -1), 999, "999B"},
+		{newSizeUnits("si", -1), 1500, "1.5kB"},
+		{newSizeUnits("si", -1), 2500000000, "2.50GB"},
+		{newSizeUnits("si", -1), 7e15, "7.00PB"},
+		{newSizeUnits("bytes", 2), 1048576, "1048576B"},
+		{newSizeUnits("iec", 0), 1536, "2KiB"},
+		{newSizeUnits("si", 3), 1234567, "1.235MB"},
+		{newSizeUnits("si", 0), 500, "500B"},
+		{newSizeUnits("iec", -1), -1, "?"},
+	}
+
+	for _, test := range tests {
+		if result := test.units.format(test.bytes); result != test.expected {
+			t.Errorf("%+v.format(%d) = %s, expected %s", test.units, test.bytes, result, test.expected)
+		}
+	}
+}
+
+// TestSizeUnitsBar tests that the bar and summary use the selected units
+func TestSizeUnitsBar(t *testing.T) {
+	p := &Progress{
+		totalSize: 3000000,
+		startTime: time.Now().Add(-time.Second),
+		barSize:   10,
+		units:     newSizeUnits("si", -1),
+	}
+	p.bytesRead.Store(1500000)
+
+	if bar := p.buildProgressBar(time.Second); !strings.Contains(bar, "1.50MB of 3.00MB (50.0%) @ 1.50MB/s") {
+		t.Errorf("Expected SI sizes in bar, got '%s'", bar)
+	}
+
+	var summary strings.Builder
+	p.writeSummary(&summary, "human")
+	if !strings.Contains(summary.String(), "Transferred 1.50MB in") {
+		t.Errorf("Expected SI sizes in summary, got '%s'", summary.String())
+	}
+}
+
+// TestValidUnits tests the accepted --units values
+func TestValidUnits(t *testing.T) {
+	for _, units := range []string{"", "iec", "si", "bytes"} {
+		if !validUnits(units) {
+			t.Errorf("Expected %q to be valid", units)
+		}
+	}
+	if validUnits("metric") {
+		t.Errorf("Expected 'metric' to be rejected")
+	}
+}
+
+// TestSizeValue tests parsing size flags and the errors for bad input
+func TestSizeValue(t *testing.T) {
+	var size int64
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&sizeValue{bytes: &size}, "size", "")
+
+	if err := fs.Parse([]string{"--size=1.5GiB"}); err != nil || size != 1536*1024*1024 {
+		t.Errorf("Expected 1.5GiB to parse, got %d, %v", size, err)
+	}
+	if s := (&sizeValue{bytes: &size}).String(); s != "1610612736" {
+		t.Errorf("Expected String() to give the byte count, got %s", s)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5X", `unknown unit "X"`},
+		{"10 MBs", `unknown unit "MBs"`},
+		{"GiB", "missing number"},
+		{"1.2.3M", `"1.2.3" is not a number`},
+		{"-1K", "must not be negative"},
+		{"9E", "must be below 8EiB"},
+		{"", "size is empty"},
+	}
+	for _, test := range tests {
+		err := fs.Parse([]string{"--size=" + test.input})
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Parsing %q: expected error containing %q, got %v", test.input, test.expected, err)
+		}
+	}
+	if size != 1536*1024*1024 {
+		t.Errorf("Expected invalid input to leave the size unchanged, got %d", size)
+	}
+}
+
+// TestApplySizeUnits tests that kB, MB... are read the way the chosen units display them
+func TestApplySizeUnits(t *testing.T) {
+	for _, system := range []string{"", "iec", "si"} {
+		var size, limit int64
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Var(&sizeValue{bytes: &size}, "size", "")
+		fs.Var(&sizeValue{bytes: &limit}, "limit", "")
+		fs.Parse([]string{"--size=500MB"})
+
+		if err := applySizeUnits(fs, system); err != nil {
+			t.Fatalf("applySizeUnits(%q) returned error: %v", system, err)
+		}
+		if shown := newSizeUnits(system, -1).format(size); system != "iec" && shown != "500.00MB" {
+			t.Errorf("Units %q: expected 500MB to show as 500.00MB, got %s (%d bytes)", system, shown, size)
+		}
+		if system == "iec" && size != 500*1024*1024 {
+			t.Errorf("Units iec: expected 500MB to be 500MiB, got %d", size)
+		}
+		if limit != 0 {
+			t.Errorf("Expected an unset size flag to stay 0, got %d", limit)
+		}
+	}
+}
+
+// TestFormatRate tests rates in bytes and in bits per second
+func TestFormatRate(t *testing.T) {
+	bits := sizeUnits{rateBits: true}
+	tests := []struct {
+		units    sizeUnits
+		rate     float64
+		expected string
+	}{
+		{sizeUnits{}, 1536, "1.5KB/s"},
+		{newSizeUnits("si", -1), 1500000, "1.50MB/s"},
+		{bits, 100, "800bit/s"},
+		{bits, 1500, "12.0Kbit/s"},
+		{bits, 12500000, "100.00Mbit/s"},
+		{bits, 125000000, "1.00Gbit/s"},
+		{sizeUnits{rateBits: true, precision: 1, fixed: true}, 117187500, "937.5Mbit/s"},
+		{bits, -1, "?"},
+	}
+
+	for _, test := range tests {
+		if result := test.units.formatRate(test.rate); result != test.expected {
+			t.Errorf("%+v.formatRate(%g) = %s, expected %s", test.units, test.rate, result, test.expected)
+		}
+	}
+}
+
+// TestRateBitsBar tests that only the rate switches to bits in the bar
+func TestRateBitsBar(t *testing.T) {
+	p := &Progress{
+		totalSize: 2 * 1024 * 1024,
+		startTime: time.Now().Add(-time.Second),
+		barSize:   10,
+		units:     sizeUnits{rateBits: true},
+	}
+	p.bytesRead.Store(1024 * 1024)
+
+	if bar := p.buildProgressBar(time.Second); !strings.Contains(bar, "1.00MB of 2.00MB (50.0%) @ 8.39Mbit/s") {
+		t.Errorf("Expected byte sizes and a bit rate, got '%s'", bar)
+	}
+	if s := p.snapshot(p.startTime.Add(time.Second)); s.RateBits != s.Rate*8 {
+		t.Errorf("Expected rate_bits to be 8 times the rate, got %+v", s)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// webhookPayload is the JSON body POSTed for each event
+type webhookPayload struct {
+	Event string `json:"event"` // start, progress, complete or error
+	Snapshot
+	Error string `json:"error,omitempty"`
+}
+
+// webhook delivers events to an HTTP endpoint in the background
+type webhook struct {
+	url        string
+	client     *http.Client
+	retries    int
+	retryDelay time.Duration
+	events     chan webhookPayload
+	wg         sync.WaitGroup
+	warn       func(msg string) // Reports failed deliveries on the display
+}
+
+// newWebhook starts a webhook sender for url that reports failures with warn
+func newWebhook(url string, timeout time.Duration, retries int, warn func(msg string)) *webhook {
+	w := &webhook{
+		url:        url,
+		client:     &http.Client{Timeout: timeout},
+		retries:    retries,
+		retryDelay: time.Second,
+		events:     make(chan webhookPayload, 16),
+		warn:       warn,
+	}
+
+	w.wg.Add(1)
+	go w.run()
+
+	return w
+}
+
+// send queues an event; milestones are dropped if the queue is full
+func (w *webhook) send(event string, st Snapshot, err error) {
+	payload := webhookPayload{Event: event, Snapshot: st}
+	if err != nil {
+		payload.Error = err.Error()
+	}
+
+	if event == "progress" {
+		select {
+		case w.events <- payload:
+		default:
+		}
+		return
+	}
+	w.events <- payload
+}
+
+// Close delivers queued events and stops the sender
+func (w *webhook) Close() {
+	close(w.events)
+	w.wg.Wait()
+}
+
+// run delivers events in order
+func (w *webhook) run() {
+	defer w.wg.Done()
+
+	for payload := range w.events {
+		if err := w.deliver(payload); err != nil {
+			w.warn(fmt.Sprintf("webhook %s event failed: %s", payload.Event, err))
+		}
+	}
+}
+
+// deliver POSTs a payload, retrying on network errors and non-2xx responses
+func (w *webhook) deliver(payload webhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = w.post(body)
+		if err == nil || attempt >= w.retries {
+			return err
+		}
+		time.Sleep(w.retryDelay * time.Duration(attempt+1))
+	}
+}
+
+// post makes a single delivery attempt
+func (w *webhook) post(body []byte) error {
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// checkWebhookMilestone sends a progress event each time another step percent completes
+func (p *Progress) checkWebhookMilestone() {
+	if p.webhook == nil || p.webhookStep <= 0 || p.totalSize <= 0 {
+		return
+	}
+
+	st := p.Snapshot()
+	if step := int(st.Percent / p.webhookStep); step > p.lastWebhookStep {
+		p.lastWebhookStep = step
+		p.webhook.send("progress", st, nil)
+	}
+}
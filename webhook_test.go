@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver records payloads POSTed to a test server
+type webhookReceiver struct {
+	mu       sync.Mutex
+	payloads []webhookPayload
+	failures int // Number of requests to reject before accepting
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	if rcv.failures > 0 {
+		rcv.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var payload webhookPayload
+	json.NewDecoder(r.Body).Decode(&payload)
+	rcv.payloads = append(rcv.payloads, payload)
+}
+
+func (rcv *webhookReceiver) events() []string {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	var events []string
+	for _, payload := range rcv.payloads {
+		events = append(events, payload.Event)
+	}
+	return events
+}
+
+// TestWebhookEvents tests start, milestone and finish events
+func TestWebhookEvents(t *testing.T) {
+	rcv := &webhookReceiver{}
+	server := httptest.NewServer(rcv)
+	defer server.Close()
+
+	p := &Progress{
+		totalSize:   1000,
+		startTime:   time.Now(),
+		webhookStep: 25,
+	}
+	p.webhook = newWebhook(server.URL, time.Second, 0, p.warn)
+
+	p.webhook.send("start", p.Snapshot(), nil)
+	for _, read := range []int64{100, 300, 400, 600} {
+		p.bytesRead.Store(read)
+		p.checkWebhookMilestone()
+	}
+	p.webhook.send("error", p.Snapshot(), errors.New("broken pipe"))
+	p.webhook.Close()
+
+	events := rcv.events()
+	expected := []string{"start", "progress", "progress", "error"}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected events %v, got %v", expected, events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Errorf("Expected event %d to be %s, got %s", i, expected[i], events[i])
+		}
+	}
+
+	if rcv.payloads[2].BytesRead != 600 || rcv.payloads[2].Percent != 60.0 {
+		t.Errorf("Expected milestone at 600 bytes (60%%), got %+v", rcv.payloads[2])
+	}
+	if rcv.payloads[3].Error != "broken pipe" {
+		t.Errorf("Expected error message in payload, got '%s'", rcv.payloads[3].Error)
+	}
+}
+
+// TestWebhookRetry tests that failed deliveries are retried
+func TestWebhookRetry(t *testing.T) {
+	rcv := &webhookReceiver{failures: 2}
+	server := httptest.NewServer(rcv)
+	defer server.Close()
+
+	w := newWebhook(server.URL, time.Second, 2, func(msg string) { t.Errorf("Unexpected warning: %s", msg) })
+	w.retryDelay = time.Millisecond
+	w.send("complete", Snapshot{BytesRead: 42, Done: true}, nil)
+	w.Close()
+
+	events := rcv.events()
+	if len(events) != 1 || events[0] != "complete" {
+		t.Errorf("Expected a single complete event after retries, got %v", events)
+	}
+}
+
+// TestWebhookDeliverFailure tests that delivery gives up after the retries
+func TestWebhookDeliverFailure(t *testing.T) {
+	rcv := &webhookReceiver{failures: 5}
+	server := httptest.NewServer(rcv)
+	defer server.Close()
+
+	w := &webhook{
+		url:        server.URL,
+		client:     &http.Client{Timeout: time.Second},
+		retries:    1,
+		retryDelay: time.Millisecond,
+	}
+
+	if err := w.deliver(webhookPayload{Event: "start"}); err == nil {
+		t.Errorf("Expected delivery to fail")
+	}
+	if rcv.failures != 3 {
+		t.Errorf("Expected 2 attempts, got %d", 5-rcv.failures)
+	}
+}
+
+// TestWebhookWarning tests that a failed delivery is reported as a log line on the display
+func TestWebhookWarning(t *testing.T) {
+	rcv := &webhookReceiver{failures: 1}
+	server := httptest.NewServer(rcv)
+	defer server.Close()
+
+	var display bytes.Buffer
+	p := &Progress{startTime: time.Now(), logMode: true, display: &display}
+	p.webhook = newWebhook(server.URL, time.Second, 0, p.warn)
+	p.webhook.send("start", p.Snapshot(), nil)
+	p.webhook.Close()
+
+	line := display.String()
+	if !strings.HasPrefix(line, "[") || !strings.Contains(line, "] Warning: webhook start event failed: unexpected status: 503") {
+		t.Errorf("Expected a timestamped warning on the display, got %q", line)
+	}
+}
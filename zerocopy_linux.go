@@ -0,0 +1,170 @@
+//go:build linux
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	spliceMove    = 0x1     // SPLICE_F_MOVE
+	spliceMore    = 0x4     // SPLICE_F_MORE
+	zeroCopyChunk = 1 << 20 // Bytes moved per call, small enough for smooth progress
+	fSetPipeSz    = 1031    // F_SETPIPE_SZ
+	pollIn        = 0x1     // POLLIN
+	pollOut       = 0x4     // POLLOUT
+)
+
+// pollFd mirrors struct pollfd for ppoll
+type pollFd struct {
+	fd      int32
+	events  int16
+	revents int16
+}
+
+// transferZeroCopy copies src to dst inside the kernel, counting bytes per chunk.
+// It returns false if the pair of files isn't supported and nothing was copied,
+// so the caller can fall back to the user-space loop.
+func (p *Progress) transferZeroCopy(src, dst *os.File, writer *bufio.Writer) (bool, error) {
+	move := p.zeroCopyMethod(src, dst)
+	if move == nil {
+		return false, nil
+	}
+
+	var moved int64
+	for {
+		chunk := int64(zeroCopyChunk)
+		if p.limit > 0 {
+			chunk = min(chunk, p.limit-moved)
+			if chunk <= 0 {
+				break
+			}
+		}
+
+		// No data is buffered in user space, so only check whether Process stopped us
+		if p.stopped() {
+			return true, nil
+		}
+
+		n, err := move(int(chunk))
+		if n > 0 {
+			moved += n
+			p.lastRead.Store(p.now().UnixNano())
+			p.bytesRead.Add(n)
+		}
+		if err != nil {
+			if moved == 0 && zeroCopyUnsupported(err) {
+				return false, nil
+			}
+			p.stopOutput(writer, "")
+			return true, fmt.Errorf("error copying data: %w", err)
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	return true, p.finishTransfer(src, writer)
+}
+
+// zeroCopyMethod picks splice for pipes and copy_file_range for regular files
+func (p *Progress) zeroCopyMethod(src, dst *os.File) func(n int) (int64, error) {
+	srcInfo, err := src.Stat()
+	if err != nil {
+		return nil
+	}
+	dstInfo, err := dst.Stat()
+	if err != nil {
+		return nil
+	}
+
+	isPipe := func(info os.FileInfo) bool { return info.Mode()&os.ModeNamedPipe != 0 }
+	isSpliceable := func(info os.FileInfo) bool {
+		return isPipe(info) || info.Mode().IsRegular() || info.Mode()&os.ModeSocket != 0
+	}
+
+	switch {
+	case (isPipe(srcInfo) || isPipe(dstInfo)) && isSpliceable(srcInfo) && isSpliceable(dstInfo):
+		srcFd, dstFd := int(src.Fd()), int(dst.Fd())
+		// Spliced pipes hold page references, so a small consumer read frees little room;
+		// a larger pipe keeps splice from waking up for every page
+		for _, f := range []struct {
+			fd   int
+			info os.FileInfo
+		}{{srcFd, srcInfo}, {dstFd, dstInfo}} {
+			if isPipe(f.info) {
+				growPipe(f.fd)
+			}
+		}
+		return func(n int) (int64, error) {
+			// Splice waits on both sides at once, so wait for each first to
+			// charge the time to the one holding up the transfer
+			if err := p.waitReady(&p.waits.input, srcFd, pollIn); err != nil {
+				return 0, err
+			}
+			if err := p.waitReady(&p.waits.output, dstFd, pollOut); err != nil {
+				return 0, err
+			}
+			for {
+				moved, err := syscall.Splice(srcFd, nil, dstFd, nil, n, spliceMove|spliceMore)
+				if err == syscall.EINTR {
+					continue
+				}
+				return moved, err
+			}
+		}
+	case srcInfo.Mode().IsRegular() && dstInfo.Mode().IsRegular():
+		// os.File.ReadFrom uses copy_file_range for a limited *os.File source
+		return func(n int) (int64, error) {
+			moved, err := io.CopyN(dst, src, int64(n))
+			if err == io.EOF {
+				err = nil
+			}
+			return moved, err
+		}
+	default:
+		return nil
+	}
+}
+
+// waitReady blocks until fd is ready for events, counting the time on timer.
+// Errors and hang-ups also end the wait, leaving splice to report them.
+func (p *Progress) waitReady(timer *waitTimer, fd int, events int16) error {
+	start := p.now()
+	timer.begin(start)
+	defer func() { timer.end(start, p.now()) }()
+
+	pfd := pollFd{fd: int32(fd), events: events}
+	for {
+		_, _, errno := syscall.Syscall6(syscall.SYS_PPOLL, uintptr(unsafe.Pointer(&pfd)), 1, 0, 0, 0, 0)
+		switch errno {
+		case 0:
+			return nil
+		case syscall.EINTR:
+			continue
+		default:
+			return fmt.Errorf("error waiting for data: %w", errno)
+		}
+	}
+}
+
+// growPipe raises the pipe buffer to zeroCopyChunk, ignoring failures from
+// older kernels or the unprivileged pipe-max-size limit
+func growPipe(fd int) {
+	syscall.Syscall(syscall.SYS_FCNTL, uintptr(fd), fSetPipeSz, zeroCopyChunk)
+}
+
+// zeroCopyUnsupported reports whether err means the kernel can't zero-copy between the files
+func zeroCopyUnsupported(err error) bool {
+	return errors.Is(err, syscall.EINVAL) ||
+		errors.Is(err, syscall.ENOSYS) ||
+		errors.Is(err, syscall.EBADF) ||
+		errors.Is(err, syscall.EXDEV) ||
+		errors.Is(err, syscall.EOPNOTSUPP)
+}
@@ -0,0 +1,211 @@
+//go:build linux
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestProcessZeroCopyPipe tests the splice path between pipes
+func TestProcessZeroCopyPipe(t *testing.T) {
+	testData := bytes.Repeat([]byte("0123456789abcdef"), 256*1024) // 4MiB
+	p := &Progress{
+		startTime:   time.Now(),
+		refreshRate: 100 * time.Millisecond,
+		quiet:       true,
+		zeroCopy:    true,
+	}
+
+	output, err := runProcess(t, p, func(w *os.File) {
+		w.Write(testData)
+		w.Close()
+	})
+	if err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+
+	if !bytes.Equal(output, testData) {
+		t.Errorf("Processed data doesn't match input data")
+	}
+	if p.bytesRead.Load() != int64(len(testData)) {
+		t.Errorf("Expected bytesRead to be %d, got %d", len(testData), p.bytesRead.Load())
+	}
+}
+
+// TestProcessZeroCopyLimit tests that --limit is honoured by the splice path
+func TestProcessZeroCopyLimit(t *testing.T) {
+	testData := bytes.Repeat([]byte("x"), 3*zeroCopyChunk)
+	p := &Progress{
+		startTime:   time.Now(),
+		refreshRate: 100 * time.Millisecond,
+		quiet:       true,
+		zeroCopy:    true,
+		limit:       zeroCopyChunk + 123,
+		drain:       true,
+	}
+
+	output, err := runProcess(t, p, func(w *os.File) {
+		w.Write(testData)
+		w.Close()
+	})
+	if err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+
+	if len(output) != zeroCopyChunk+123 {
+		t.Errorf("Expected %d bytes of output, got %d", zeroCopyChunk+123, len(output))
+	}
+	if p.stopReason != "limit" {
+		t.Errorf("Expected stop reason limit, got '%s'", p.stopReason)
+	}
+}
+
+// TestTransferZeroCopyFiles tests the copy_file_range path between regular files
+func TestTransferZeroCopyFiles(t *testing.T) {
+	dir := t.TempDir()
+	testData := bytes.Repeat([]byte("progzer"), 100000)
+	os.WriteFile(filepath.Join(dir, "src"), testData, 0644)
+
+	src, _ := os.Open(filepath.Join(dir, "src"))
+	defer src.Close()
+	dst, _ := os.Create(filepath.Join(dir, "dst"))
+	defer dst.Close()
+
+	p := &Progress{startTime: time.Now()}
+	handled, err := p.transferZeroCopy(src, dst, bufio.NewWriter(dst))
+	if !handled || err != nil {
+		t.Fatalf("Expected zero-copy to handle regular files, got handled=%v err=%v", handled, err)
+	}
+
+	copied, _ := os.ReadFile(filepath.Join(dir, "dst"))
+	if !bytes.Equal(copied, testData) {
+		t.Errorf("Copied data doesn't match source")
+	}
+	if !p.finished.Load() || p.bytesRead.Load() != int64(len(testData)) {
+		t.Errorf("Expected finished transfer of %d bytes, got %d", len(testData), p.bytesRead.Load())
+	}
+}
+
+// TestTransferZeroCopyUnsupported tests falling back for unsupported file pairs
+func TestTransferZeroCopyUnsupported(t *testing.T) {
+	devNull, _ := os.Open(os.DevNull)
+	defer devNull.Close()
+	out, _ := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	defer out.Close()
+
+	p := &Progress{startTime: time.Now()}
+	if handled, _ := p.transferZeroCopy(devNull, out, bufio.NewWriter(out)); handled {
+		t.Errorf("Expected character devices to fall back to the user-space loop")
+	}
+}
+
+// benchmarkProcess pipes size bytes from a regular file through Process into a pipe
+func benchmarkProcess(b *testing.B, zeroCopy bool) {
+	const size = 64 << 20
+
+	path := filepath.Join(b.TempDir(), "input")
+	os.WriteFile(path, bytes.Repeat([]byte("x"), size), 0644)
+
+	oldStdin := os.Stdin
+	oldStdout := os.Stdout
+	defer func() {
+		os.Stdin = oldStdin
+		os.Stdout = oldStdout
+	}()
+
+	b.SetBytes(size)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		in, _ := os.Open(path)
+		outR, outW, _ := os.Pipe()
+		os.Stdin = in
+		os.Stdout = outW
+
+		// Consume the output with large reads, as a fast downstream would
+		drained := make(chan struct{})
+		go func() {
+			buf := make([]byte, 1<<20)
+			for {
+				if _, err := outR.Read(buf); err != nil {
+					break
+				}
+			}
+			close(drained)
+		}()
+
+		p := &Progress{
+			startTime:   time.Now(),
+			refreshRate: time.Second,
+			quiet:       true,
+			zeroCopy:    zeroCopy,
+		}
+		if err := p.Process(context.Background()); err != nil {
+			b.Fatalf("Process() returned error: %v", err)
+		}
+
+		outW.Close()
+		<-drained
+		outR.Close()
+		in.Close()
+	}
+}
+
+// BenchmarkProcessUserSpace measures the buffered read/write loop
+func BenchmarkProcessUserSpace(b *testing.B) {
+	benchmarkProcess(b, false)
+}
+
+// BenchmarkProcessZeroCopy measures the splice fast path
+func BenchmarkProcessZeroCopy(b *testing.B) {
+	benchmarkProcess(b, true)
+}
+
+// TestProcessZeroCopyWaits tests that the splice path charges a slow consumer as output wait
+func TestProcessZeroCopyWaits(t *testing.T) {
+	testData := bytes.Repeat([]byte("x"), 4*zeroCopyChunk)
+	r, w, _ := os.Pipe()
+	defer r.Close()
+	outR, outW, _ := os.Pipe()
+	defer outR.Close()
+
+	p := &Progress{
+		startTime:   time.Now(),
+		refreshRate: 10 * time.Millisecond,
+		quiet:       true,
+		zeroCopy:    true,
+		input:       r,
+		output:      outW,
+	}
+
+	go func() {
+		w.Write(testData)
+		w.Close()
+	}()
+	received := make(chan int)
+	go func() {
+		// Start reading late so the output pipe fills up
+		time.Sleep(200 * time.Millisecond)
+		data, _ := io.ReadAll(outR)
+		received <- len(data)
+	}()
+
+	if err := p.Process(context.Background()); err != nil {
+		t.Fatalf("Process() returned error: %v", err)
+	}
+	outW.Close()
+	if n := <-received; n != len(testData) {
+		t.Errorf("Expected %d bytes of output, got %d", len(testData), n)
+	}
+
+	if _, output := p.waits.totals(time.Now()); output < 100*time.Millisecond {
+		t.Errorf("Expected at least 100ms of output wait, got %s", output)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package main
+
+import (
+	"bufio"
+	"os"
+)
+
+// transferZeroCopy is only implemented on Linux; elsewhere the user-space loop is used
+func (p *Progress) transferZeroCopy(src, dst *os.File, writer *bufio.Writer) (bool, error) {
+	return false, nil
+}